@@ -8,27 +8,39 @@ import (
 	"github.com/qx66/auto-cert/internal/biz"
 	"github.com/qx66/auto-cert/internal/conf"
 	"github.com/qx66/auto-cert/internal/tasks"
+	"github.com/qx66/auto-cert/pkg/step"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v3"
 	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const (
-	directUrl = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	directUrl       = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	shutdownTimeout = 10 * time.Second
 )
 
 type app struct {
-	accountUseCase *biz.AccountUseCase
-	orderUseCase   *biz.OrderUseCase
-	task           *tasks.Task
+	accountUseCase       *biz.AccountUseCase
+	orderUseCase         *biz.OrderUseCase
+	authorizationUseCase *biz.AuthorizationUseCase
+	http01Solver         *step.Http01Solver
+	tlsAlpn01Solver      *step.TlsAlpn01Solver
+	task                 *tasks.Task
 }
 
-func newApp(accountUseCase *biz.AccountUseCase, orderUseCase *biz.OrderUseCase, task *tasks.Task) *app {
+func newApp(accountUseCase *biz.AccountUseCase, orderUseCase *biz.OrderUseCase, authorizationUseCase *biz.AuthorizationUseCase,
+	http01Solver *step.Http01Solver, tlsAlpn01Solver *step.TlsAlpn01Solver, task *tasks.Task) *app {
 	return &app{
-		accountUseCase: accountUseCase,
-		orderUseCase:   orderUseCase,
-		task:           task,
+		accountUseCase:       accountUseCase,
+		orderUseCase:         orderUseCase,
+		authorizationUseCase: authorizationUseCase,
+		http01Solver:         http01Solver,
+		tlsAlpn01Solver:      tlsAlpn01Solver,
+		task:                 task,
 	}
 }
 
@@ -48,8 +60,9 @@ func main() {
 	}
 	defer logger.Sync()
 	
-	//
-	ctx := context.Background()
+	// 收到退出信号时取消 ctx
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	
@@ -84,7 +97,7 @@ func main() {
 	
 	//
 	var bootstrap conf.Bootstrap
-	err = yaml.Unmarshal(buf.Bytes(), &bootstrap)
+	err = conf.Unmarshal(buf.Bytes(), &bootstrap)
 	if err != nil {
 		logger.Error(
 			"序列化配置失败",
@@ -94,7 +107,7 @@ func main() {
 	}
 	
 	//
-	app, clean, err := initApp(bootstrap.Data, bootstrap.Dns, logger)
+	app, clean, err := initApp(bootstrap.Data, bootstrap.Dns, bootstrap.Acme, bootstrap.Challenge, logger)
 	defer clean()
 	
 	if err != nil {
@@ -107,26 +120,95 @@ func main() {
 	
 	route := gin.New()
 	route.POST("/account", app.accountUseCase.CreateAccount)
+	route.POST("/account/recover", app.accountUseCase.RecoverAccount)
 	route.GET("/account/:uuid", app.accountUseCase.GetAccount)
+	route.PATCH("/account/:uuid", app.accountUseCase.UpdateAccount)
 	route.DELETE("/account/:uuid", app.accountUseCase.DelAccount)
+	route.PUT("/account/:uuid/dns-provider", app.accountUseCase.UpdateAccountDnsProvider)
+	route.POST("/account/:uuid/key-rollover", app.accountUseCase.KeyRollover)
+	route.GET("/account/:uuid/orders", app.orderUseCase.ListAccountOrders)
+	
+	route.POST("/account/:uuid/authorizations", app.authorizationUseCase.CreateAuthorization)
+	route.GET("/account/:uuid/authorizations", app.authorizationUseCase.ListAuthorization)
+	route.GET("/account/:uuid/authorizations/:authzUuid/challenge", app.authorizationUseCase.GetAuthorizationChallenge)
 	
 	route.POST("/order", app.orderUseCase.CreateOrder)
 	route.GET("/order/:uuid", app.orderUseCase.GetOrder)
 	route.GET("/orders", app.orderUseCase.ListOrder)
 	
 	route.GET("/order/:uuid/authorizations", app.orderUseCase.GetOrderAuthorizations)
+	route.POST("/order/:uuid/authorizations/deactivate", app.orderUseCase.DeactivateOrderAuthorizations)
 	route.GET("/order/:uuid/challenge", app.orderUseCase.GetOrderAuthorizationsChallenge)
 	route.GET("/order/:uuid/finalize", app.orderUseCase.FinalizeOrder)
 	
 	route.GET("/order/:uuid/certificate", app.orderUseCase.GetOrderCertificate)
+	route.POST("/order/:uuid/revoke", app.orderUseCase.RevokeOrderCertificate)
+	
+	// http-01 验证: 配置 addr 时单独监听 (CA 通过 80 端口访问), 否则由当前服务响应 (需要反向代理 80 端口)
+	if app.http01Solver != nil {
+		http01Addr := bootstrap.Challenge.GetHttp01().GetAddr()
+		if http01Addr == "" {
+			route.GET("/.well-known/acme-challenge/:token", gin.WrapH(app.http01Solver))
+		} else {
+			go func() {
+				err := http.ListenAndServe(http01Addr, app.http01Solver)
+				if err != nil {
+					logger.Error(
+						"启动http-01验证服务失败",
+						zap.String("addr", http01Addr),
+						zap.Error(err),
+					)
+				}
+			}()
+		}
+	}
+	
+	// tls-alpn-01 验证: CA 通过 443 端口以 ALPN acme-tls/1 访问
+	if app.tlsAlpn01Solver != nil {
+		tlsAlpn01Addr := bootstrap.Challenge.GetTlsAlpn01().GetAddr()
+		if tlsAlpn01Addr == "" {
+			tlsAlpn01Addr = ":443"
+		}
+		
+		go func() {
+			err := app.tlsAlpn01Solver.ListenAndServe(tlsAlpn01Addr)
+			if err != nil {
+				logger.Error(
+					"启动tls-alpn-01验证服务失败",
+					zap.String("addr", tlsAlpn01Addr),
+					zap.Error(err),
+				)
+			}
+		}()
+	}
 	
 	app.task.CronJob(ctx)
 	
-	err = route.Run(":18080")
+	server := &http.Server{Addr: ":18080", Handler: route}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			logger.Error(
+				"启动程序失败",
+				zap.Error(err),
+			)
+			cancel()
+		}
+	}()
+	
+	// 退出时停止接收请求, 等待后台验证结束并移除 token/TXT 记录
+	<-ctx.Done()
+	
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+	
+	err = server.Shutdown(shutdownCtx)
 	if err != nil {
 		logger.Error(
-			"启动程序失败",
+			"停止HTTP服务失败",
 			zap.Error(err),
 		)
 	}
+	
+	app.orderUseCase.Shutdown()
 }
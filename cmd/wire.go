@@ -12,7 +12,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func initApp(*conf.Data, *conf.Dns, *zap.Logger) (*app, func(), error) {
+func initApp(*conf.Data, *conf.Dns, *conf.Acme, *conf.Challenge, *zap.Logger) (*app, func(), error) {
 	panic(wire.Build(
 		data.ProviderSet,
 		biz.ProviderSet,
@@ -16,17 +16,27 @@ import (
 
 // Injectors from wire.go:
 
-func initApp(confData *conf.Data, dns *conf.Dns, logger *zap.Logger) (*app, func(), error) {
+func initApp(confData *conf.Data, dns *conf.Dns, acme *conf.Acme, challenge *conf.Challenge, logger *zap.Logger) (*app, func(), error) {
 	dataData, cleanup, err := data.NewData(confData, logger)
 	if err != nil {
 		return nil, nil, err
 	}
 	accountRepo := data.NewAccountDataSource(dataData)
-	accountUseCase := biz.NewAccountUseCase(accountRepo, logger)
+	client := biz.NewAcmeClient(acme)
+	dnsProviders, err := biz.NewDNSProviders(dns)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	accountUseCase := biz.NewAccountUseCase(accountRepo, client, dnsProviders, logger)
 	orderRepo := data.NewOrderDataSource(dataData)
-	orderUseCase := biz.NewOrderUseCase(orderRepo, accountRepo, dns, logger)
+	http01Solver := biz.NewHttp01Solver(challenge)
+	tlsAlpn01Solver := biz.NewTlsAlpn01Solver(challenge)
+	orderUseCase := biz.NewOrderUseCase(orderRepo, accountRepo, client, dns, acme, http01Solver, tlsAlpn01Solver, dnsProviders, logger)
+	authorizationRepo := data.NewAuthorizationDataSource(dataData)
+	authorizationUseCase := biz.NewAuthorizationUseCase(authorizationRepo, accountRepo, client, dns, logger)
 	task := tasks.NewTask(orderUseCase, logger)
-	mainApp := newApp(accountUseCase, orderUseCase, task)
+	mainApp := newApp(accountUseCase, orderUseCase, authorizationUseCase, http01Solver, tlsAlpn01Solver, task)
 	return mainApp, func() {
 		cleanup()
 	}, nil
@@ -13,6 +13,9 @@ create table if not exists `account`
     private_key             text,
     status                  varchar(20) comment '状态: valid,deactivated,revoked',
     url                     text,
+    orders_url              text comment '账户订单列表url',
+    next_attempt_at         bigint default 0 comment '触发CA限流后, 允许再次请求的时间',
+    dns_provider            varchar(50) default '' comment '未匹配域名后缀时使用的DNSProvider',
     create_time             bigint
 ) comment '用户key';
 
@@ -20,21 +23,40 @@ create table if not exists `account`
 drop table if exists `order`;
 create table if not exists `order`
 (
-    uuid           varchar(50) primary key comment '订单uuid',
-    account_uuid   varchar(50) comment '账户uuid',
-    order_url      text comment '订单url, location',
-    status         varchar(20) comment 'pending/ready/processing/valid/invalid',
-    expires        varchar(100) comment '订单失效时间, 由服务商或CA决定',
-    not_before     varchar(100),
-    not_after      varchar(100),
-    identifiers    JSON comment 'object',
-    authorizations JSON,
-    finalize       text,
-    private_key    text comment '私钥',
-    csr            text comment 'base64 csr',
-    certificate    text comment '证书',
-    create_time    bigint
+    uuid              varchar(50) primary key comment '订单uuid',
+    account_uuid      varchar(50) comment '账户uuid',
+    order_url         text comment '订单url, location',
+    status            varchar(20) comment 'pending/ready/processing/valid/invalid',
+    expires           varchar(100) comment '订单失效时间, 由服务商或CA决定',
+    not_before        varchar(100),
+    not_after         varchar(100),
+    identifiers       JSON comment 'object',
+    authorizations    JSON,
+    finalize          text,
+    private_key       text comment '私钥',
+    csr               text comment 'base64 csr',
+    certificate       text comment '证书',
+    next_attempt_at   bigint default 0 comment 'CA要求的Retry-After, 定时任务在此时间之前跳过该订单',
+    revoked_at        bigint default 0 comment '证书撤销时间, 0为未撤销',
+    revocation_reason int default 0 comment '证书撤销原因, RFC 5280 CRLReason',
+    preferred_chain   varchar(255) default '' comment '优先使用的证书链, 最顶层证书的签发者CN',
+    replaces          varchar(255) default '' comment '续期订单替换的证书ARI标识',
+    replaced_by       varchar(50) default '' comment '续期后新订单的uuid',
+    renewal_at        bigint default 0 comment '续期时间, 0为未安排',
+    renewal_check_at  bigint default 0 comment '下次查询ARI续期信息的时间',
+    profile           varchar(64) default '' comment '证书配置 (ACME profile), 为空时为CA默认配置',
+    create_time       bigint
 ) comment '订单';
 
 
-
+drop table if exists `authorization`;
+create table if not exists `authorization`
+(
+    uuid         varchar(50) primary key comment '预授权uuid',
+    account_uuid varchar(50) comment '账户uuid',
+    identifier   varchar(255) comment '域名',
+    url          text comment 'authorization url, location',
+    status       varchar(20) comment 'pending/valid/invalid/deactivated/expired/revoked',
+    expires      varchar(100) comment '预授权失效时间',
+    create_time  bigint
+) comment '预授权';
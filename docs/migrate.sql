@@ -0,0 +1,55 @@
+-- 从旧版本升级时按顺序执行尚未执行过的语句, 全新部署直接使用 init.sql
+
+
+-- CA 限流 (Retry-After)
+alter table `account`
+    add column next_attempt_at bigint default 0 comment '触发CA限流后, 允许再次请求的时间' after url;
+alter table `order`
+    add column next_attempt_at bigint default 0 comment 'CA要求的Retry-After, 定时任务在此时间之前跳过该订单' after certificate;
+
+
+-- 证书撤销
+alter table `order`
+    add column revoked_at        bigint default 0 comment '证书撤销时间, 0为未撤销' after next_attempt_at,
+    add column revocation_reason int default 0 comment '证书撤销原因, RFC 5280 CRLReason' after revoked_at;
+
+
+-- 预授权 (newAuthz)
+create table if not exists `authorization`
+(
+    uuid         varchar(50) primary key comment '预授权uuid',
+    account_uuid varchar(50) comment '账户uuid',
+    identifier   varchar(255) comment '域名',
+    url          text comment 'authorization url, location',
+    status       varchar(20) comment 'pending/valid/invalid/deactivated/expired/revoked',
+    expires      varchar(100) comment '预授权失效时间',
+    create_time  bigint
+) comment '预授权';
+
+
+-- 账户订单列表
+alter table `account`
+    add column orders_url text comment '账户订单列表url' after url;
+
+
+-- 备用证书链
+alter table `order`
+    add column preferred_chain varchar(255) default '' comment '优先使用的证书链, 最顶层证书的签发者CN' after revocation_reason;
+
+
+-- ARI 续期
+alter table `order`
+    add column replaces         varchar(255) default '' comment '续期订单替换的证书ARI标识' after preferred_chain,
+    add column replaced_by      varchar(50) default '' comment '续期后新订单的uuid' after replaces,
+    add column renewal_at       bigint default 0 comment '续期时间, 0为未安排' after replaced_by,
+    add column renewal_check_at bigint default 0 comment '下次查询ARI续期信息的时间' after renewal_at;
+
+
+-- ACME profile
+alter table `order`
+    add column profile varchar(64) default '' comment '证书配置 (ACME profile), 为空时为CA默认配置' after renewal_check_at;
+
+
+-- DNSProvider
+alter table `account`
+    add column dns_provider varchar(50) default '' comment '未匹配域名后缀时使用的DNSProvider' after next_attempt_at;
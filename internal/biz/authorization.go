@@ -1,12 +1,13 @@
 package biz
 
 import (
-	"bytes"
 	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/qx66/auto-cert/internal/biz/common"
+	"github.com/qx66/auto-cert/internal/conf"
 	"github.com/qx66/auto-cert/pkg/step"
 	"go.uber.org/zap"
+	"time"
 )
 
 // 获取订单认证信息
@@ -23,6 +24,18 @@ type DnsChallenge struct {
 	Token      string `json:"token"`
 	Status     string `json:"status"`
 	Result     bool   `json:"result"`
+	
+	Propagation *step.PropagationReport `json:"propagation,omitempty"` // 各权威服务器的检查结果
+}
+
+// dns.propagationTimeout 为 0 时只检查一次, 不等待传播
+
+func newPropagationOptions(dns *conf.Dns) step.PropagationOptions {
+	return step.PropagationOptions{
+		Nameservers: dns.GetDns(),
+		Timeout:     time.Duration(dns.GetPropagationTimeout()) * time.Second,
+		Interval:    time.Duration(dns.GetPropagationInterval()) * time.Second,
+	}
 }
 
 func (orderUseCase *OrderUseCase) GetOrderAuthorizations(c *gin.Context) {
@@ -67,7 +80,7 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizations(c *gin.Context) {
 		return
 	}
 	
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	
 	if err != nil {
 		orderUseCase.logger.Error(
@@ -78,61 +91,25 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizations(c *gin.Context) {
 		return
 	}
 	
-	// 4. 获取 directory
-	directory, err := step.Directory(directoryUrl)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取Directory失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 5. 获取 nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 6. 获取订单 Authorizations 信息
+	// 4. 获取订单 Authorizations 信息
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
 	var replyAuthorizations []step.Authorization
 	var replyDnsChallenges []DnsChallenge
-	replayNonce := &nonce
 	
 	for _, authorization := range authorizations {
-		// 6.1. 获取 Signature
-		getOrderAuthorizationContent, err := step.GetSignature(authorization, *replayNonce, "", account.Url, privateKey)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取Signature失败",
-				zap.String("authorization", authorization),
-				zap.Error(err),
-			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-			return
-		}
-		
-		getOrderAuthorizationBody := bytes.NewBuffer([]byte(getOrderAuthorizationContent.FullSerialize()))
-		// 6.2. 获取 Authorization
-		authoriz, nonce, err := step.GetOrderAuthorization(authorization, getOrderAuthorizationBody.Bytes())
+		// 4.1. 获取 Authorization
+		authoriz, err := orderUseCase.client.GetOrderAuthorization(c.Request.Context(), authorization, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
 				"获取authorization失败",
 				zap.String("authorization", authorization),
 				zap.Error(err),
 			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			common.ResponseAcmeError(c, err)
 			return
 		}
 		
 		replyAuthorizations = append(replyAuthorizations, authoriz)
-		replayNonce = &nonce
 		
 		if authoriz.Status != "pending" {
 			break
@@ -154,23 +131,20 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizations(c *gin.Context) {
 				
 				fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
 				
-				// VerifyTxtRecord
-				var verifyResult bool = false
-				err = step.VerifyTxtRecord(fqdn, record, orderUseCase.dns)
-				if err != nil {
-					verifyResult = false
-				} else {
-					verifyResult = true
-				}
+				// 检查一次 TXT 记录, 不等待传播
+				options := orderUseCase.propagation
+				options.Timeout = 0
+				report, err := step.CheckTxtPropagation(c.Request.Context(), fqdn, []string{record}, options)
 				
 				replyDnsChallenges = append(replyDnsChallenges, DnsChallenge{
-					DomainName: authoriz.Identifier.Value,
-					FQDN:       fqdn,
-					Type:       "TXT",
-					Value:      record,
-					Token:      challenge.Token,
-					Status:     challenge.Status,
-					Result:     verifyResult,
+					DomainName:  authoriz.Identifier.Value,
+					FQDN:        fqdn,
+					Type:        "TXT",
+					Value:       record,
+					Token:       challenge.Token,
+					Status:      challenge.Status,
+					Result:      err == nil,
+					Propagation: &report,
 				})
 			}
 		}
@@ -179,3 +153,125 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizations(c *gin.Context) {
 	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "authorizations": replyAuthorizations, "dnsChallenges": replyDnsChallenges})
 	return
 }
+
+// 停用订单 Authorizations, Domains 为空时停用订单的全部 Authorizations
+// 域名转交其他团队后, CA 缓存的 valid authorization 仍可被本账户使用, 需要主动停用
+
+type DeactivateOrderAuthorizationsReq struct {
+	UserUuid string   `json:"userUuid,omitempty" validate:"required"`
+	Domains  []string `json:"domains,omitempty"`
+}
+
+func (orderUseCase *OrderUseCase) DeactivateOrderAuthorizations(c *gin.Context) {
+	orderUuid := c.Param("uuid")
+	var req DeactivateOrderAuthorizationsReq
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	// 1. 获取订单
+	order, err := orderUseCase.orderRepo.GetOrder(c.Request.Context(), req.UserUuid, orderUuid)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"获取订单失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 反序列化订单 Authorizations
+	var authorizations []string
+	err = json.Unmarshal(order.Authorizations, &authorizations)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"反序列化订单authorizations信息失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 3. 获取账户
+	account, err := orderUseCase.accountRepo.GetAccount(c.Request.Context(), req.UserUuid)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"获取用户信息失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		orderUseCase.logger.Error(
+			"解析用户私钥失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	domains := make(map[string]bool)
+	for _, domain := range req.Domains {
+		domains[domain] = true
+	}
+	
+	// 4. 停用 Authorizations
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	var replyAuthorizations []step.Authorization
+	
+	for _, authorization := range authorizations {
+		// 4.1. 获取 Authorization
+		authoriz, err := orderUseCase.client.GetOrderAuthorization(c.Request.Context(), authorization, accountKey)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"获取authorization失败",
+				zap.String("authorization", authorization),
+				zap.Error(err),
+			)
+			common.ResponseAcmeError(c, err)
+			return
+		}
+		
+		// 通配符 authorization 的 identifier 不包含 *.
+		domain := authoriz.Identifier.Value
+		if authoriz.Wildcard {
+			domain = "*." + domain
+		}
+		
+		if len(domains) > 0 && !domains[domain] {
+			continue
+		}
+		
+		// 4.2. 只有 pending 和 valid 状态可以停用
+		if authoriz.Status != "pending" && authoriz.Status != "valid" {
+			replyAuthorizations = append(replyAuthorizations, authoriz)
+			continue
+		}
+		
+		authoriz, err = orderUseCase.client.DeactivatingAuthorization(c.Request.Context(), authorization, accountKey)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"停用authorization失败",
+				zap.String("authorization", authorization),
+				zap.Error(err),
+			)
+			common.ResponseAcmeError(c, err)
+			return
+		}
+		
+		orderUseCase.logger.Info(
+			"停用authorization成功",
+			zap.String("orderUuid", orderUuid),
+			zap.String("domain", domain),
+		)
+		
+		replyAuthorizations = append(replyAuthorizations, authoriz)
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "authorizations": replyAuthorizations})
+	return
+}
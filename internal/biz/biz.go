@@ -2,6 +2,7 @@ package biz
 
 import (
 	"github.com/google/wire"
+	"github.com/qx66/auto-cert/internal/conf"
 	"github.com/qx66/auto-cert/pkg/step"
 )
 
@@ -9,4 +10,16 @@ const (
 	directoryUrl = step.LetEncryptDirectoryProdUrl
 )
 
-var ProviderSet = wire.NewSet(NewAccountUseCase, NewOrderUseCase)
+var ProviderSet = wire.NewSet(NewAcmeClient, NewHttp01Solver, NewTlsAlpn01Solver, NewDNSProviders, NewAccountUseCase, NewOrderUseCase, NewAuthorizationUseCase)
+
+// ACME 客户端, 所有 UseCase 共享同一个 http.Client
+// 未配置 acme.directoryUrl 时使用 Let's Encrypt
+
+func NewAcmeClient(acme *conf.Acme) *step.Client {
+	url := directoryUrl
+	if acme.GetDirectoryUrl() != "" {
+		url = acme.GetDirectoryUrl()
+	}
+	
+	return step.NewClient(url, nil, "")
+}
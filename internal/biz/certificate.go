@@ -1,12 +1,13 @@
 package biz
 
 import (
-	"bytes"
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/qx66/auto-cert/internal/biz/common"
 	"github.com/qx66/auto-cert/pkg/step"
 	"github.com/startopsz/rule/pkg/ssl"
 	"go.uber.org/zap"
+	"time"
 )
 
 // 获取订单证书
@@ -52,7 +53,7 @@ func (orderUseCase *OrderUseCase) GetOrderCertificate(c *gin.Context) {
 		return
 	}
 	
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	
 	if err != nil {
 		orderUseCase.logger.Error(
@@ -63,109 +64,212 @@ func (orderUseCase *OrderUseCase) GetOrderCertificate(c *gin.Context) {
 		return
 	}
 	
-	// 3. 获取 directory
-	directory, err := step.Directory(directoryUrl)
+	// 3. 获取订单
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	orderResp, err := orderUseCase.client.GetOrder(c.Request.Context(), order.OrderUrl, accountKey)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Directory失败",
+			"获取Order失败",
 			zap.Error(err),
 		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	if orderResp.Status != "valid" {
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	if orderResp.Certificate == "" {
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	// 4. 获取 nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
+	// 4. 获取订单证书
+	certificate, err := orderUseCase.downloadCertificate(c.Request.Context(), order, orderResp.Certificate, accountKey)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
+			"获取证书失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		common.ResponseAcmeError(c, err)
 		return
 	}
 	
-	// 5. 获取 Signature
-	getOrderContent, err := step.GetSignature(order.OrderUrl, nonce, "", account.Url, privateKey)
+	// 5. 更新订单证书数据库信息
+	cert, err := ssl.ParseSSLCertificate([]byte(certificate))
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Signature失败",
+			"解析证书失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	getOrderBody := bytes.NewBuffer([]byte(getOrderContent.FullSerialize()))
+	err = orderUseCase.orderRepo.UpdateOrderCertificate(c.Request.Context(), orderUuid, certificate,
+		cert.NotBefore.String(), cert.NotAfter.String())
 	
-	// 6. 获取订单
-	orderResp, nonce, err := step.GetOrder(order.OrderUrl, getOrderBody.Bytes())
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Order失败",
+			"更新订单证书失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	if orderResp.Status != "valid" {
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "certificate": certificate})
+	return
+}
+
+// 下载证书, 按订单或全局 preferredChain 选择证书链, 备用证书链下载失败时在已下载的证书链中选择
+
+func (orderUseCase *OrderUseCase) downloadCertificate(ctx context.Context, order Order, certificateUrl string, key step.AccountKey) (string, error) {
+	chains, err := orderUseCase.client.DownloadCertificates(ctx, certificateUrl, key)
+	if len(chains) == 0 {
+		return "", err
 	}
 	
-	if orderResp.Certificate == "" {
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+	if err != nil {
+		orderUseCase.logger.Error(
+			"下载备用证书链失败",
+			zap.String("orderUuid", order.Uuid),
+			zap.Error(err),
+		)
+	}
+	
+	preferredChain := order.PreferredChain
+	if preferredChain == "" {
+		preferredChain = orderUseCase.preferredChain
+	}
+	
+	return step.SelectPreferredChain(chains, preferredChain), nil
+}
+
+// 撤销订单证书
+// Reason 为 RFC 5280 CRLReason, keyCompromise (1) 时使用证书私钥签名, 其他原因使用账户私钥签名
+
+type RevokeOrderCertificateReq struct {
+	UserUuid string `json:"userUuid,omitempty" validate:"required"`
+	Reason   int    `json:"reason" validate:"min=0,max=10,ne=7"`
+}
+
+func (orderUseCase *OrderUseCase) RevokeOrderCertificate(c *gin.Context) {
+	orderUuid := c.Param("uuid")
+	var req RevokeOrderCertificateReq
+	
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
 		return
 	}
 	
-	// 7. 获取 Signature
-	downloadCertificateContent, err := step.GetSignature(orderResp.Certificate, nonce, "", account.Url, privateKey)
+	// 1. 获取订单
+	order, err := orderUseCase.orderRepo.GetOrder(c.Request.Context(), req.UserUuid, orderUuid)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Signature失败",
+			"获取订单失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	downloadCertificateBody := bytes.NewBuffer([]byte(downloadCertificateContent.FullSerialize()))
+	if order.Certificate == "" {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "订单尚未获取证书"})
+		return
+	}
+	
+	if order.RevokedAt != 0 {
+		c.JSON(200, gin.H{"errCode": 0, "errMsg": "证书已撤销"})
+		return
+	}
 	
-	// 8. 获取订单证书
-	certificate, err := step.DownloadCertificate(orderResp.Certificate, downloadCertificateBody.Bytes())
+	// 2. 获取签名私钥
+	var key step.AccountKey
+	if req.Reason == step.RevocationReasonKeyCompromise {
+		certPrivateKey, err := parsePrivateKey([]byte(order.PrivateKey))
+		if err != nil {
+			orderUseCase.logger.Error(
+				"解析证书私钥失败",
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+		
+		key = step.AccountKey{PrivateKey: certPrivateKey}
+	} else {
+		account, err := orderUseCase.accountRepo.GetAccount(c.Request.Context(), req.UserUuid)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"获取用户信息失败",
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+		
+		privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+		if err != nil {
+			orderUseCase.logger.Error(
+				"解析用户私钥失败",
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+		
+		key = step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	}
+	
+	// 3. 获取 directory
+	directory, err := orderUseCase.client.Directory(c.Request.Context())
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取证书失败",
+			"获取Directory失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		common.ResponseAcmeError(c, err)
 		return
 	}
 	
-	// 9. 更新订单证书数据库信息
-	cert, err := ssl.ParseSSLCertificate([]byte(certificate))
+	// 4. 撤销证书, CA 返回 alreadyRevoked 时同样记录为已撤销
+	payload, err := step.GenerateRevokeCertificatePayload(order.Certificate, req.Reason)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"解析证书失败",
+			"生成撤销证书Payload失败",
+			zap.String("orderUuid", orderUuid),
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	err = orderUseCase.orderRepo.UpdateOrderCertificate(c.Request.Context(), orderUuid, certificate,
-		cert.NotBefore.String(), cert.NotAfter.String())
+	err = orderUseCase.client.RevokeCertificate(c.Request.Context(), directory.RevokeCert, payload, key)
+	if err != nil && !step.IsProblemType(err, step.ErrorAlreadyRevoked) {
+		orderUseCase.logger.Error(
+			"撤销证书失败",
+			zap.String("orderUuid", orderUuid),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
 	
+	// 5. 更新订单撤销信息
+	err = orderUseCase.orderRepo.UpdateOrderRevoked(c.Request.Context(), orderUuid, time.Now().Unix(), req.Reason)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"更新订单证书失败",
+			"更新订单撤销信息失败",
+			zap.String("orderUuid", orderUuid),
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "certificate": certificate})
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
 	return
 }
@@ -1,9 +1,7 @@
 package biz
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/qx66/auto-cert/internal/biz/common"
 	"github.com/qx66/auto-cert/pkg/step"
@@ -59,7 +57,7 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizationsChallenge(c *gin.Context
 		return
 	}
 	
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	
 	if err != nil {
 		orderUseCase.logger.Error(
@@ -70,63 +68,38 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizationsChallenge(c *gin.Context
 		return
 	}
 	
-	// 4. 获取 directory
-	directory, err := step.Directory(directoryUrl)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取Directory失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 5. 获取 nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 6. authorizations (验证) - preCheck(预检查)
+	// 4. authorizations (验证) - preCheck(预检查)
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
 	var replyAuthorizations []step.Authorization
 	var replyDnsChallenges []DnsChallenge
-	replayNonce := &nonce
+	var replySolverChallenges []SolverChallenge
 	var preCheckAuthorizationChallenge bool = true
 	
-	for _, authorization := range authorizations {
-		// 6.1. authorizations GetSignature
-		getOrderAuthorizationContent, err := step.GetSignature(authorization, *replayNonce, "", account.Url, privateKey)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取Signature失败",
-				zap.String("authorization", authorization),
-				zap.Error(err),
-			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-			return
+	var solverChallenges []pendingChallenge
+	var dnsChallengeGroups []*dnsChallengeGroup
+	
+	// 预检查未通过或出错返回时, 移除尚未交给 goWait 的 solver challenge
+	var waitingSolverChallenges int
+	defer func() {
+		for _, pending := range solverChallenges[waitingSolverChallenges:] {
+			orderUseCase.cleanUpSolverChallenge(pending.authoriz, pending.challenge)
 		}
-		
-		getOrderAuthorizationBody := bytes.NewBuffer([]byte(getOrderAuthorizationContent.FullSerialize()))
-		
-		// 6.2. GetOrderAuthorization
-		authoriz, nonce, err := step.GetOrderAuthorization(authorization, getOrderAuthorizationBody.Bytes())
+	}()
+	
+	for _, authorization := range authorizations {
+		// 4.1. GetOrderAuthorization
+		authoriz, err := orderUseCase.client.GetOrderAuthorization(c.Request.Context(), authorization, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
 				"获取authorization失败",
 				zap.String("authorization", authorization),
 				zap.Error(err),
 			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			common.ResponseAcmeError(c, err)
 			return
 		}
 		
 		replyAuthorizations = append(replyAuthorizations, authoriz)
-		replayNonce = &nonce
 		
 		// 如果状态为 valid -- 会造成 authorizations 和 dnsChallenges 不对称
 		if authoriz.Status == "valid" {
@@ -145,207 +118,162 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizationsChallenge(c *gin.Context
 			break
 		}
 		
-		// 6.3. GetOrderAuthorization Challenges
+		// http-01/tls-alpn-01 由内置 solver 响应, 无需预检查
+		if challenge, ok := orderUseCase.solverChallenge(authoriz); ok {
+			if challenge.Status != "pending" {
+				preCheckAuthorizationChallenge = false
+				continue
+			}
+			
+			solverChallenge, err := orderUseCase.presentSolverChallenge(authoriz, challenge, privateKey)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"生成auth challenge key失败",
+					zap.String("authorization", authorization),
+					zap.Error(err),
+				)
+				c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+				return
+			}
+			
+			replySolverChallenges = append(replySolverChallenges, solverChallenge)
+			solverChallenges = append(solverChallenges, pendingChallenge{authorization: authorization, authoriz: authoriz, challenge: challenge})
+			continue
+		}
+		
+		// ip 标识不提供 dns-01 验证, 需要使用 http-01 或 tls-alpn-01
+		if authoriz.Identifier.IsIP() {
+			orderUseCase.logger.Info(
+				"IP标识需要使用 http-01 或 tls-alpn-01 验证",
+				zap.String("identifier", authoriz.Identifier.Value),
+				zap.String("authorization", authorization),
+			)
+			preCheckAuthorizationChallenge = false
+			continue
+		}
+		
+		// 4.2. GetOrderAuthorization Challenges, 按 FQDN 分组
 		for _, challenge := range authoriz.Challenges {
-			if challenge.Type == "dns-01" {
-				
-				if authoriz.Status == "valid" {
-					continue
-				}
-				
-				if challenge.Status != "pending" {
-					preCheckAuthorizationChallenge = false
-					break
-				}
-				
-				fqdn := fmt.Sprintf("_acme-challenge.%s", authoriz.Identifier.Value)
-				// 6.3.1 Get Order Authorization DNS auth
-				authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
-				if err != nil {
-					orderUseCase.logger.Error(
-						"生成auth challenge key失败",
-						zap.String("authorization", authorization),
-						zap.Error(err),
-					)
-					c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-					return
-				}
-				
-				fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
-				
-				// 预检查 VerifyTxtRecord
-				err = step.VerifyTxtRecord(fqdn, record, orderUseCase.dns)
-				if err != nil {
-					orderUseCase.logger.Error(
-						"Order Authorization Challenge 验证DNS失败",
-						zap.Error(err),
-					)
-					
-					preCheckAuthorizationChallenge = false
-					
-					replyDnsChallenges = append(replyDnsChallenges, DnsChallenge{
-						DomainName: authoriz.Identifier.Value,
-						FQDN:       fqdn,
-						Type:       "TXT",
-						Token:      challenge.Token,
-						Value:      record,
-						Status:     challenge.Status,
-						Result:     false,
-					})
-				} else {
-					replyDnsChallenges = append(replyDnsChallenges, DnsChallenge{
-						DomainName: authoriz.Identifier.Value,
-						FQDN:       fqdn,
-						Type:       "TXT",
-						Token:      challenge.Token,
-						Value:      record,
-						Status:     challenge.Status,
-						Result:     true,
-					})
-				}
+			if challenge.Type != "dns-01" {
+				continue
 			}
+			
+			if challenge.Status != "pending" {
+				preCheckAuthorizationChallenge = false
+				break
+			}
+			
+			// 4.2.1 Get Order Authorization DNS auth
+			authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"生成auth challenge key失败",
+					zap.String("authorization", authorization),
+					zap.Error(err),
+				)
+				c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+				return
+			}
+			
+			fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
+			dnsChallengeGroups = addDnsChallenge(dnsChallengeGroups, fqdn, record,
+				pendingChallenge{authorization: authorization, authoriz: authoriz, challenge: challenge})
 		}
 	}
 	
-	// 6.2 预检查未通过
+	// 4.3. 预检查 TXT 记录, 同一 FQDN 的全部 TXT 值需要同时在全部权威服务器生效
+	for _, group := range dnsChallengeGroups {
+		report, err := step.CheckTxtPropagation(c.Request.Context(), group.fqdn, group.values, orderUseCase.propagation)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"Order Authorization Challenge 验证DNS失败",
+				zap.String("fqdn", group.fqdn),
+				zap.Error(err),
+			)
+			preCheckAuthorizationChallenge = false
+		}
+		
+		for i, pending := range group.challenges {
+			replyDnsChallenges = append(replyDnsChallenges, DnsChallenge{
+				DomainName:  pending.authoriz.Identifier.Value,
+				FQDN:        group.fqdn,
+				Type:        "TXT",
+				Token:       pending.challenge.Token,
+				Value:       group.values[i],
+				Status:      pending.challenge.Status,
+				Result:      err == nil,
+				Propagation: &report,
+			})
+		}
+	}
+	
+	// 4.4 预检查未通过
 	if !preCheckAuthorizationChallenge {
 		c.JSON(200, gin.H{
 			"errCode":                        0,
 			"errMsg":                         "fail",
 			"authorizations":                 replyAuthorizations,
 			"dnsChallenges":                  replyDnsChallenges,
+			"solverChallenges":               replySolverChallenges,
 			"preCheckAuthorizationChallenge": preCheckAuthorizationChallenge,
 		})
 		return
 	}
 	
-	// 7. 实际执行 authorization Challenge
-	for _, authorization := range authorizations {
-		//
-		orderUseCase.logger.Info(
-			"开始执行 authorization Challenge",
-			zap.String("orderUuid", orderUuid),
-			zap.String("authorization", authorization),
-		)
-		
-		// 7.1. authorizations GetSignature
-		getOrderAuthorizationContent, err := step.GetSignature(authorization, *replayNonce, "", account.Url, privateKey)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取Signature失败",
-				zap.String("orderUuid", orderUuid),
-				zap.String("authorization", authorization),
-				zap.Error(err),
-			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-			return
-		}
-		
-		getOrderAuthorizationBody := bytes.NewBuffer([]byte(getOrderAuthorizationContent.FullSerialize()))
-		
-		// 7.2. GetOrderAuthorization
-		authoriz, nonce, err := step.GetOrderAuthorization(authorization, getOrderAuthorizationBody.Bytes())
+	// 5. 实际执行 authorization Challenge
+	orderUseCase.logger.Info(
+		"开始执行 authorization Challenge",
+		zap.String("orderUuid", orderUuid),
+	)
+	
+	// 5.1. 内置 solver 执行 Challenge 后等待验证结束并移除 token
+	for _, pending := range solverChallenges {
+		challenge, err := orderUseCase.client.GetOrderAuthorizationChallenge(c.Request.Context(), pending.challenge.Url, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取authorization失败",
-				zap.String("authorization", authorization),
+				"获取authorization challenge失败",
+				zap.String("authorization", pending.authorization),
 				zap.Error(err),
 			)
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			common.ResponseAcmeError(c, err)
 			return
 		}
 		
-		replyAuthorizations = append(replyAuthorizations, authoriz)
-		replayNonce = &nonce
-		
-		if authoriz.Status == "valid" {
-			continue
-		}
-		
-		// 这种状态判断有问题 -- 理论上在预检部分没有发现，在这部分也不会发现
-		if authoriz.Status != "pending" {
-			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-			return
-			//break
-		}
+		pending := pending
+		orderUseCase.goWait(func() {
+			orderUseCase.waitSolverChallenge(pending.authorization, pending.authoriz, pending.challenge, accountKey)
+		})
+		waitingSolverChallenges++
 		
-		// 7.3. GetOrderAuthorization Challenges
-		for _, challenge := range authoriz.Challenges {
-			
-			if challenge.Type == "dns-01" {
-				fqdn := fmt.Sprintf("_acme-challenge.%s", authoriz.Identifier.Value)
-				// 7.3.1 Get Order Authorization DNS auth
-				authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
-				if err != nil {
-					orderUseCase.logger.Error(
-						"生成auth challenge key失败",
-						zap.String("authorization", authorization),
-						zap.Error(err),
-					)
-					c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-					return
-				}
-				
-				fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
-				
-				replyDnsChallenges = append(replyDnsChallenges, DnsChallenge{
-					DomainName: authoriz.Identifier.Value,
-					FQDN:       fqdn,
-					Type:       "TXT",
-					Token:      challenge.Token,
-					Value:      record,
-					Status:     challenge.Status,
-					Result:     true,
-				})
-				
-				// VerifyTxtRecord
-				err = step.VerifyTxtRecord(fqdn, record, orderUseCase.dns)
-				if err != nil {
-					orderUseCase.logger.Error(
-						"Order Authorization Challenge 验证DNS失败",
-						zap.Error(err),
-					)
-					break
-				}
-				
-				// 7.3.2 GetSignature
-				getOrderAuthorizationChallengeContent, err := step.GetSignature(challenge.Url, *replayNonce, "{}", account.Url, privateKey)
-				
-				if err != nil {
-					orderUseCase.logger.Error(
-						"获取Signature失败",
-						zap.String("authorization", authorization),
-						zap.Error(err),
-					)
-					c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-					return
-				}
-				
-				getOrderAuthorizationChallengeBody := bytes.NewBuffer([]byte(getOrderAuthorizationChallengeContent.FullSerialize()))
-				
-				// 7.3.3 GetOrderAuthorizationChallenge
-				challenge, nonce, err := step.GetOrderAuthorizationChallenge(challenge.Url, getOrderAuthorizationChallengeBody.Bytes())
-				if err != nil {
-					orderUseCase.logger.Error(
-						"获取authorization challenge失败",
-						zap.String("authorization", authorization),
-						zap.Error(err),
-					)
-					c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error", "challenge": challenge})
-					return
-				}
-				
-				orderUseCase.logger.Info(
-					"执行 authorization Challenge 成功",
-					zap.String("orderUuid", orderUuid),
-					zap.String("authorization", authorization),
-					zap.String("fqdn", fqdn),
-					zap.String("record", record),
-					zap.Any("challenge", challenge),
+		orderUseCase.logger.Info(
+			"执行 authorization Challenge 成功",
+			zap.String("orderUuid", orderUuid),
+			zap.String("authorization", pending.authorization),
+			zap.Any("challenge", challenge),
+		)
+	}
+	
+	// 5.2. dns-01 同一 FQDN 的 TXT 值已全部生效, 依次执行 Challenge
+	for _, group := range dnsChallengeGroups {
+		for _, pending := range group.challenges {
+			challenge, err := orderUseCase.client.GetOrderAuthorizationChallenge(c.Request.Context(), pending.challenge.Url, accountKey)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"获取authorization challenge失败",
+					zap.String("authorization", pending.authorization),
+					zap.Error(err),
 				)
-				
-				replayNonce = &nonce
+				common.ResponseAcmeError(c, err)
+				return
 			}
+			
+			orderUseCase.logger.Info(
+				"执行 authorization Challenge 成功",
+				zap.String("orderUuid", orderUuid),
+				zap.String("authorization", pending.authorization),
+				zap.String("fqdn", group.fqdn),
+				zap.Any("challenge", challenge),
+			)
 		}
 	}
 	
@@ -354,7 +282,49 @@ func (orderUseCase *OrderUseCase) GetOrderAuthorizationsChallenge(c *gin.Context
 		"errMsg":                         "ok",
 		"authorizations":                 replyAuthorizations,
 		"dnsChallenges":                  replyDnsChallenges,
+		"solverChallenges":               replySolverChallenges,
 		"preCheckAuthorizationChallenge": preCheckAuthorizationChallenge,
 	})
 	return
 }
+
+// 待执行的 challenge
+
+type pendingChallenge struct {
+	authorization string
+	authoriz      step.Authorization
+	challenge     step.Challenge
+}
+
+// 同一 FQDN 的 dns-01 challenge, 如 *.example.com 与 example.com 共用 _acme-challenge.example.com,
+// 两个 TXT 值需要同时存在, 全部生效后才能执行其中任意一个 challenge
+
+type dnsChallengeGroup struct {
+	fqdn       string
+	values     []string // 与 challenges 一一对应
+	challenges []pendingChallenge
+}
+
+// 相同的 TXT 值对应同一个 challenge (如 domains 中重复的域名), 只保留一次
+
+func addDnsChallenge(groups []*dnsChallengeGroup, fqdn, value string, pending pendingChallenge) []*dnsChallengeGroup {
+	for _, group := range groups {
+		if group.fqdn == fqdn {
+			for _, v := range group.values {
+				if v == value {
+					return groups
+				}
+			}
+			
+			group.values = append(group.values, value)
+			group.challenges = append(group.challenges, pending)
+			return groups
+		}
+	}
+	
+	return append(groups, &dnsChallengeGroup{
+		fqdn:       fqdn,
+		values:     []string{value},
+		challenges: []pendingChallenge{pending},
+	})
+}
@@ -0,0 +1,97 @@
+package biz
+
+import (
+	"github.com/qx66/auto-cert/pkg/step"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAddDnsChallenge(t *testing.T) {
+	newPending := func(authorization, domain, token string) pendingChallenge {
+		return pendingChallenge{
+			authorization: authorization,
+			authoriz:      step.Authorization{Identifier: step.NewIdentifier(domain)},
+			challenge:     step.Challenge{Type: "dns-01", Token: token},
+		}
+	}
+	
+	type challenge struct {
+		fqdn    string
+		value   string
+		pending pendingChallenge
+	}
+	
+	tests := []struct {
+		name       string
+		challenges []challenge
+		groups     []*dnsChallengeGroup
+	}{
+		{
+			// 通配符域名与主域名共用 _acme-challenge.example.com
+			name: "wildcard and apex",
+			challenges: []challenge{
+				{"_acme-challenge.example.com.", "value-1", newPending("authz-1", "*.example.com", "token-1")},
+				{"_acme-challenge.example.com.", "value-2", newPending("authz-2", "example.com", "token-2")},
+			},
+			groups: []*dnsChallengeGroup{
+				{
+					fqdn:   "_acme-challenge.example.com.",
+					values: []string{"value-1", "value-2"},
+					challenges: []pendingChallenge{
+						newPending("authz-1", "*.example.com", "token-1"),
+						newPending("authz-2", "example.com", "token-2"),
+					},
+				},
+			},
+		},
+		{
+			name: "distinct names",
+			challenges: []challenge{
+				{"_acme-challenge.www.example.com.", "value-1", newPending("authz-1", "www.example.com", "token-1")},
+				{"_acme-challenge.example.com.", "value-2", newPending("authz-2", "example.com", "token-2")},
+			},
+			groups: []*dnsChallengeGroup{
+				{
+					fqdn:       "_acme-challenge.www.example.com.",
+					values:     []string{"value-1"},
+					challenges: []pendingChallenge{newPending("authz-1", "www.example.com", "token-1")},
+				},
+				{
+					fqdn:       "_acme-challenge.example.com.",
+					values:     []string{"value-2"},
+					challenges: []pendingChallenge{newPending("authz-2", "example.com", "token-2")},
+				},
+			},
+		},
+		{
+			name: "duplicate value",
+			challenges: []challenge{
+				{"_acme-challenge.example.com.", "value-1", newPending("authz-1", "example.com", "token-1")},
+				{"_acme-challenge.example.com.", "value-1", newPending("authz-1", "example.com", "token-1")},
+			},
+			groups: []*dnsChallengeGroup{
+				{
+					fqdn:       "_acme-challenge.example.com.",
+					values:     []string{"value-1"},
+					challenges: []pendingChallenge{newPending("authz-1", "example.com", "token-1")},
+				},
+			},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var groups []*dnsChallengeGroup
+			for _, c := range tt.challenges {
+				groups = addDnsChallenge(groups, c.fqdn, c.value, c.pending)
+			}
+			
+			require.Equal(t, tt.groups, groups)
+			
+			// TXT 值与 challenge 一一对应
+			for _, group := range groups {
+				require.Len(t, group.challenges, len(group.values))
+			}
+		})
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"github.com/qx66/auto-cert/pkg/step"
 	userV1 "github.com/startopsz/api/user/v1"
 	"github.com/startopsz/rule/pkg/response/errCode"
 	"google.golang.org/grpc/metadata"
 	"reflect"
+	"strconv"
 )
 
 // 统一对请求请求参数为 application/json 类型的数据进行 Unmarshal
@@ -78,6 +80,33 @@ func ResponseGrpcError(c *gin.Context, err error) {
 	}
 }
 
+// 处理 ACME 服务端返回错误的请求, 根据 Problem 类型返回对应的状态码
+
+func ResponseAcmeError(c *gin.Context, err error) {
+	c.Set("error", err.Error())
+	
+	var problem *step.Problem
+	if !errors.As(err, &problem) {
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		c.Abort()
+		return
+	}
+	
+	if retryAfter, ok := step.RetryAfter(err); ok {
+		c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	}
+	
+	switch {
+	case step.IsRateLimited(err):
+		c.JSON(429, gin.H{"errCode": 429, "errMsg": "Too Many Requests", "problem": problem})
+	case step.IsUserActionRequired(err):
+		c.JSON(403, gin.H{"errCode": 403, "errMsg": "User Action Required", "problem": problem})
+	default:
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error", "problem": problem})
+	}
+	c.Abort()
+}
+
 // 继承 gin.Context 返回 grpc Context
 
 func GetGrpcCtx(c *gin.Context) context.Context {
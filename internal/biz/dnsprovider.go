@@ -0,0 +1,217 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/qx66/auto-cert/internal/conf"
+	"github.com/qx66/auto-cert/pkg/step"
+	"go.uber.org/zap"
+	"time"
+)
+
+// dns-01 DNSProvider, 按 dns.providers 配置创建, 定时任务通过 provider 自动添加 TXT 记录并在验证结束后删除
+
+// 删除 TXT 记录的最长时间
+const dnsProviderCleanUpTimeout = time.Minute
+
+// 未配置 dns.providers 时返回空的 DNSProviders
+
+func NewDNSProviders(dns *conf.Dns) (*step.DNSProviders, error) {
+	dnsProviders := step.NewDNSProviders()
+	for _, provider := range dns.GetProviders() {
+		dnsProvider, err := newDNSProvider(dns, provider)
+		if err != nil {
+			return nil, err
+		}
+		
+		err = dnsProviders.Register(provider.GetName(), dnsProvider, provider.GetDomains()...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	return dnsProviders, nil
+}
+
+// 按 type 创建 DNSProvider
+
+func newDNSProvider(dns *conf.Dns, provider *conf.Dns_Provider) (step.DNSProvider, error) {
+	timeout := time.Duration(provider.GetPropagationTimeout()) * time.Second
+	interval := time.Duration(provider.GetPropagationInterval()) * time.Second
+	
+	switch provider.GetType() {
+	case "rfc2136":
+		rfc2136 := provider.GetRfc2136()
+		var zones []step.Rfc2136Zone
+		for _, zone := range rfc2136.GetZones() {
+			zones = append(zones, step.Rfc2136Zone{
+				Domain: zone.GetDomain(),
+				Zone:   zone.GetZone(),
+				Server: zone.GetServer(),
+			})
+		}
+		
+		return step.NewRfc2136Provider(step.Rfc2136Config{
+			Server:              rfc2136.GetServer(),
+			TsigKey:             rfc2136.GetTsigKey(),
+			TsigSecret:          rfc2136.GetTsigSecret(),
+			TsigAlgorithm:       rfc2136.GetTsigAlgorithm(),
+			TTL:                 rfc2136.GetTtl(),
+			Zones:               zones,
+			Nameservers:         dns.GetDns(),
+			PropagationTimeout:  timeout,
+			PropagationInterval: interval,
+		})
+	case "alidns":
+		alidns := provider.GetAlidns()
+		return step.NewAlidnsProvider(step.AlidnsConfig{
+			AccessKeyId:         alidns.GetAccessKeyId(),
+			AccessKeySecret:     alidns.GetAccessKeySecret(),
+			Endpoint:            alidns.GetEndpoint(),
+			TTL:                 int(alidns.GetTtl()),
+			PropagationTimeout:  timeout,
+			PropagationInterval: interval,
+		})
+	case "dnspod":
+		dnspod := provider.GetDnspod()
+		return step.NewDnspodProvider(step.DnspodConfig{
+			SecretId:            dnspod.GetSecretId(),
+			SecretKey:           dnspod.GetSecretKey(),
+			Endpoint:            dnspod.GetEndpoint(),
+			RecordLine:          dnspod.GetRecordLine(),
+			TTL:                 int(dnspod.GetTtl()),
+			PropagationTimeout:  timeout,
+			PropagationInterval: interval,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported dns provider type %q for %s", provider.GetType(), provider.GetName())
+	}
+}
+
+// provider 未指定等待时间时使用 dns.propagationTimeout/propagationInterval
+
+func (orderUseCase *OrderUseCase) dnsProviderPropagation(provider step.DNSProvider) step.PropagationOptions {
+	propagation := orderUseCase.propagation
+	
+	timeout, interval := provider.Timeout()
+	if timeout > 0 {
+		propagation.Timeout = timeout
+	}
+	
+	if interval > 0 {
+		propagation.Interval = interval
+	}
+	
+	return propagation
+}
+
+// 添加同一 FQDN 的全部 TXT 值, 返回实际添加记录的名称 (跟随 CNAME)
+// 失败时保留已添加的记录, 下次定时任务重新添加, 在验证结束或订单失效后删除
+
+func (orderUseCase *OrderUseCase) presentDnsChallenge(ctx context.Context, provider step.DNSProvider, group *dnsChallengeGroup) (string, error) {
+	fqdn, err := step.ResolveChallengeFqdn(ctx, group.fqdn, orderUseCase.propagation.Nameservers)
+	if err != nil {
+		return "", err
+	}
+	
+	for _, value := range group.values {
+		err = provider.Present(ctx, fqdn, value)
+		if err != nil {
+			return "", err
+		}
+	}
+	
+	return fqdn, nil
+}
+
+func (orderUseCase *OrderUseCase) cleanUpDnsChallenge(provider step.DNSProvider, fqdn string, values []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsProviderCleanUpTimeout)
+	defer cancel()
+	
+	for _, value := range values {
+		err := provider.CleanUp(ctx, fqdn, value)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"删除TXT记录失败",
+				zap.String("fqdn", fqdn),
+				zap.String("value", value),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// 等待同一 FQDN 的全部 authorization 验证结束后删除 TXT 记录, 在执行 challenge 后通过 goWait 运行, 程序退出时同样删除
+
+func (orderUseCase *OrderUseCase) waitDnsChallenge(provider step.DNSProvider, fqdn string, values []string, challenges []pendingChallenge, accountKey step.AccountKey) {
+	defer orderUseCase.cleanUpDnsChallenge(provider, fqdn, values)
+	
+	for _, pending := range challenges {
+		orderUseCase.waitAuthorization(pending.authorization, pending.challenge.Type, accountKey)
+	}
+}
+
+// 订单失效 (invalid) 时删除 DNSProvider 添加的 TXT 记录, 传播检查未通过时记录会保留到验证结束或订单失效
+
+func (orderUseCase *OrderUseCase) cleanUpAbandonedDnsChallenges(ctx context.Context, order Order, account Account, accountKey step.AccountKey) {
+	var authorizations []string
+	err := json.Unmarshal(order.Authorizations, &authorizations)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"反序列化订单authorizations信息失败",
+			zap.String("orderUuid", order.Uuid),
+			zap.Error(err),
+		)
+		return
+	}
+	
+	var dnsChallengeGroups []*dnsChallengeGroup
+	for _, authorization := range authorizations {
+		authoriz, err := orderUseCase.client.GetOrderAuthorization(ctx, authorization, accountKey)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"获取authorization失败",
+				zap.String("authorization", authorization),
+				zap.String("orderUuid", order.Uuid),
+				zap.Error(err),
+			)
+			continue
+		}
+		
+		for _, challenge := range authoriz.Challenges {
+			if challenge.Type != "dns-01" {
+				continue
+			}
+			
+			authKey, err := step.GetKeyAuthorization(challenge.Token, accountKey.PrivateKey)
+			if err != nil {
+				continue
+			}
+			
+			fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
+			dnsChallengeGroups = addDnsChallenge(dnsChallengeGroups, fqdn, record,
+				pendingChallenge{authorization: authorization, authoriz: authoriz, challenge: challenge})
+		}
+	}
+	
+	for _, group := range dnsChallengeGroups {
+		_, provider, ok := orderUseCase.dnsProviders.Lookup(group.challenges[0].authoriz.Identifier.Value, account.DnsProvider)
+		if !ok {
+			continue
+		}
+		
+		fqdn, err := step.ResolveChallengeFqdn(ctx, group.fqdn, orderUseCase.propagation.Nameservers)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"查找TXT记录名称失败",
+				zap.String("orderUuid", order.Uuid),
+				zap.String("fqdn", group.fqdn),
+				zap.Error(err),
+			)
+			continue
+		}
+		
+		orderUseCase.cleanUpDnsChallenge(provider, fqdn, group.values)
+	}
+}
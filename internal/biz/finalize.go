@@ -1,7 +1,6 @@
 package biz
 
 import (
-	"bytes"
 	"github.com/gin-gonic/gin"
 	"github.com/qx66/auto-cert/internal/biz/common"
 	"github.com/qx66/auto-cert/pkg/step"
@@ -44,7 +43,7 @@ func (orderUseCase *OrderUseCase) FinalizeOrder(c *gin.Context) {
 		return
 	}
 	
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	if err != nil {
 		orderUseCase.logger.Error(
 			"解析用户私钥失败",
@@ -54,29 +53,7 @@ func (orderUseCase *OrderUseCase) FinalizeOrder(c *gin.Context) {
 		return
 	}
 	
-	// 3. 获取 directory
-	directory, err := step.Directory(directoryUrl)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取Directory失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 4. 获取 nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	// 5. 获取 Finalize Payload
+	// 3. 获取 Finalize Payload
 	finalizeOrderPayload, err := step.GenerateFinalizeOrderPayload(order.Csr)
 	if err != nil {
 		orderUseCase.logger.Error(
@@ -87,27 +64,15 @@ func (orderUseCase *OrderUseCase) FinalizeOrder(c *gin.Context) {
 		return
 	}
 	
-	// 6. 获取 Signature
-	finalizeOrderContent, err := step.GetSignature(order.Finalize, nonce, finalizeOrderPayload, account.Url, privateKey)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"GetSignature失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
-	}
-	
-	finalizeOrderBody := bytes.NewBuffer([]byte(finalizeOrderContent.FullSerialize()))
-	
-	// 7. Finalize Order
-	finalizeOrder, err := step.FinalizeOrder(order.Finalize, finalizeOrderBody.Bytes())
+	// 4. Finalize Order
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	finalizeOrder, err := orderUseCase.client.FinalizeOrder(c.Request.Context(), order.Finalize, finalizeOrderPayload, accountKey)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"FinalizeOrder失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		common.ResponseAcmeError(c, err)
 		return
 	}
 	
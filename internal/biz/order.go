@@ -1,10 +1,10 @@
 package biz
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,24 +12,34 @@ import (
 	"github.com/qx66/auto-cert/internal/conf"
 	"github.com/qx66/auto-cert/pkg/step"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
 type Order struct {
-	Uuid           string `json:"uuid"`
-	AccountUuid    string `json:"accountUuid"`
-	OrderUrl       string `json:"orderUrl"`
-	Status         string `json:"status"`
-	Expires        string `json:"expires"`
-	NotBefore      string `json:"notBefore"`
-	NotAfter       string `json:"notAfter"`
-	Identifiers    []byte `json:"identifiers"`
-	Authorizations []byte `json:"authorizations"`
-	Finalize       string `json:"finalize"`
-	PrivateKey     string `json:"privateKey"`  // 证书私钥
-	Csr            string `json:"csr"`         // 证书私钥生成的CSR
-	Certificate    string `json:"certificate"` // 证书内容
-	CreateTime     int64  `json:"createTime"`
+	Uuid             string `json:"uuid"`
+	AccountUuid      string `json:"accountUuid"`
+	OrderUrl         string `json:"orderUrl"`
+	Status           string `json:"status"`
+	Expires          string `json:"expires"`
+	NotBefore        string `json:"notBefore"`
+	NotAfter         string `json:"notAfter"`
+	Identifiers      []byte `json:"identifiers"`
+	Authorizations   []byte `json:"authorizations"`
+	Finalize         string `json:"finalize"`
+	PrivateKey       string `json:"privateKey"`       // 证书私钥
+	Csr              string `json:"csr"`              // 证书私钥生成的CSR
+	Certificate      string `json:"certificate"`      // 证书内容
+	NextAttemptAt    int64  `json:"nextAttemptAt"`    // CA 要求的 Retry-After, 定时任务在此之前跳过该订单
+	RevokedAt        int64  `json:"revokedAt"`        // 证书撤销时间, 0 为未撤销
+	RevocationReason int    `json:"revocationReason"` // 证书撤销原因, RFC 5280 CRLReason
+	PreferredChain   string `json:"preferredChain"`   // 优先使用的证书链 (最顶层证书的签发者 CN), 为空时使用全局配置
+	Replaces         string `json:"replaces"`         // 续期订单替换的证书 ARI 标识
+	ReplacedBy       string `json:"replacedBy"`       // 续期后新订单的 uuid
+	RenewalAt        int64  `json:"renewalAt"`        // 在 ARI 建议窗口内随机选择的续期时间, 0 为未安排
+	RenewalCheckAt   int64  `json:"renewalCheckAt"`   // 下次查询 ARI 续期信息的时间
+	Profile          string `json:"profile"`          // 证书配置 (ACME profile), 为空时为 CA 默认配置
+	CreateTime       int64  `json:"createTime"`
 }
 
 func (order *Order) TableName() string {
@@ -47,29 +57,65 @@ type OrderRepo interface {
 	ExistOrder(ctx context.Context, orderUrl string) (bool, error)
 	UpdateOrderCertificate(ctx context.Context, orderUuid, certificate, notBefore, notAfter string) error
 	UpdateOrderStatus(ctx context.Context, orderUuid, status string) error
+	UpdateOrderNextAttemptAt(ctx context.Context, orderUuid string, nextAttemptAt int64) error
+	UpdateOrderRevoked(ctx context.Context, orderUuid string, revokedAt int64, reason int) error
+	
+	ListRenewalCheckOrder(ctx context.Context, now int64) ([]Order, error)
+	ListRenewalOrder(ctx context.Context, now int64) ([]Order, error)
+	UpdateOrderRenewal(ctx context.Context, orderUuid string, renewalAt, renewalCheckAt int64) error
+	UpdateOrderReplacedBy(ctx context.Context, orderUuid, replacedBy string) error
+	ClearOrderReplacedBy(ctx context.Context, replacedBy string) error
 }
 
 type OrderUseCase struct {
-	orderRepo   OrderRepo
-	accountRepo AccountRepo
-	dns         []string
-	logger      *zap.Logger
+	orderRepo       OrderRepo
+	accountRepo     AccountRepo
+	client          *step.Client
+	propagation     step.PropagationOptions
+	preferredChain  string
+	http01Solver    *step.Http01Solver    // 未启用 http-01 时为 nil
+	tlsAlpn01Solver *step.TlsAlpn01Solver // 未启用 tls-alpn-01 时为 nil
+	dnsProviders    *step.DNSProviders
+	logger          *zap.Logger
+	
+	// 执行 challenge 后在后台等待验证结束并移除 token/TXT 记录, Shutdown 时取消并等待清理结束
+	waitCtx    context.Context
+	waitCancel context.CancelFunc
+	waitGroup  sync.WaitGroup
+	waitMutex  sync.Mutex
+	shutdown   bool
 }
 
-func NewOrderUseCase(orderRepo OrderRepo, accountRepo AccountRepo, dns *conf.Dns, logger *zap.Logger) *OrderUseCase {
+func NewOrderUseCase(orderRepo OrderRepo, accountRepo AccountRepo, client *step.Client, dns *conf.Dns, acme *conf.Acme, http01Solver *step.Http01Solver,
+	tlsAlpn01Solver *step.TlsAlpn01Solver, dnsProviders *step.DNSProviders, logger *zap.Logger) *OrderUseCase {
+	waitCtx, waitCancel := context.WithCancel(context.Background())
 	return &OrderUseCase{
-		orderRepo:   orderRepo,
-		accountRepo: accountRepo,
-		dns:         dns.Dns,
-		logger:      logger,
+		orderRepo:       orderRepo,
+		accountRepo:     accountRepo,
+		client:          client,
+		propagation:     newPropagationOptions(dns),
+		preferredChain:  acme.GetPreferredChain(),
+		http01Solver:    http01Solver,
+		tlsAlpn01Solver: tlsAlpn01Solver,
+		dnsProviders:    dnsProviders,
+		logger:          logger,
+		waitCtx:         waitCtx,
+		waitCancel:      waitCancel,
 	}
 }
 
 // 创建订单
 
+// PreferredChain 为空时使用全局配置 acme.preferredChain
+// Profile 必须为 CA directory meta profiles 中的名称, NotBefore/NotAfter 为 RFC 3339 格式的请求有效期
+
 type CreateOrderReq struct {
-	UserUuid string   `json:"userUuid,omitempty" validate:"required"`
-	Domains  []string `json:"domains,omitempty" validate:"required"`
+	UserUuid       string    `json:"userUuid,omitempty" validate:"required"`
+	Domains        []string  `json:"domains,omitempty" validate:"required"`
+	PreferredChain string    `json:"preferredChain,omitempty"`
+	Profile        string    `json:"profile,omitempty"`
+	NotBefore      time.Time `json:"notBefore,omitempty"`
+	NotAfter       time.Time `json:"notAfter,omitempty"`
 }
 
 func (orderUseCase *OrderUseCase) CreateOrder(c *gin.Context) {
@@ -90,7 +136,7 @@ func (orderUseCase *OrderUseCase) CreateOrder(c *gin.Context) {
 		return
 	}
 	
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	if err != nil {
 		orderUseCase.logger.Error(
 			"解析用户私钥失败",
@@ -100,105 +146,123 @@ func (orderUseCase *OrderUseCase) CreateOrder(c *gin.Context) {
 		return
 	}
 	
-	// 2. 获取 directory
-	directory, err := step.Directory(directoryUrl)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取Directory失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+	// 2. 创建订单
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	order, orderResponse, err := orderUseCase.newOrder(c.Request.Context(), req.UserUuid, accountKey, req.Domains, newOrderOptions{
+		preferredChain: req.PreferredChain,
+		profile:        req.Profile,
+		notBefore:      req.NotBefore,
+		notAfter:       req.NotAfter,
+	})
+	if errors.Is(err, errOrderAlreadyExists) {
+		c.JSON(200, gin.H{"errCode": 0, "errMsg": "order already exists"})
 		return
 	}
 	
-	// 3. 获取 nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
-	if err != nil {
-		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
-			zap.Error(err),
-		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+	if errors.Is(err, errInvalidOrderOptions) {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": err.Error()})
 		return
 	}
 	
-	// 4. Payload
-	var identifiers []step.Identifier
-	for _, domain := range req.Domains {
-		identifiers = append(identifiers, step.Identifier{
-			Type:  "dns",
-			Value: domain,
-		})
+	if err != nil {
+		common.ResponseAcmeError(c, err)
+		return
 	}
 	
-	orderPayload, err := step.GenerateNewOrderPayload(identifiers)
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "order": orderResponse, "orderUrl": order.OrderUrl, "orderUuid": order.Uuid})
+	return
+}
+
+var (
+	errOrderAlreadyExists  = errors.New("order already exists")
+	errInvalidOrderOptions = errors.New("invalid order options")
+)
+
+// preferredChain 为空时使用全局配置, replaces 为续期时被替换证书的 ARI 标识
+
+type newOrderOptions struct {
+	preferredChain string
+	replaces       string
+	profile        string
+	notBefore      time.Time
+	notAfter       time.Time
+}
+
+// 新建订单, 生成证书私钥和 CSR 并记录数据库, 手动创建订单与定时续期共用
+
+func (orderUseCase *OrderUseCase) newOrder(ctx context.Context, accountUuid string, accountKey step.AccountKey, domains []string, options newOrderOptions) (Order, step.OrderResponse, error) {
+	var order Order
+	var orderResponse step.OrderResponse
+	
+	// 1. 获取 directory
+	directory, err := orderUseCase.client.Directory(ctx)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"生成payload信息失败",
+			"获取Directory失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
-	// 5. GetSignature
-	orderSignedContent, err := step.GetSignature(directory.NewOrder, nonce, orderPayload, account.Url, privateKey)
+	if options.profile != "" && !directory.SupportsProfile(options.profile) {
+		return order, orderResponse, fmt.Errorf("%w: CA 不支持证书配置 %s", errInvalidOrderOptions, options.profile)
+	}
+	
+	// 2. Payload, IP 字面量为 ip 标识 (RFC 8738)
+	var identifiers []step.Identifier
+	for _, domain := range domains {
+		identifiers = append(identifiers, step.NewIdentifier(domain))
+	}
+	
+	orderPayload, err := step.GenerateNewOrderPayload(identifiers, step.NewOrderOptions{
+		Replaces:  options.replaces,
+		Profile:   options.profile,
+		NotBefore: options.notBefore,
+		NotAfter:  options.notAfter,
+	})
 	if err != nil {
 		orderUseCase.logger.Error(
-			"生成Signature信息失败",
+			"生成payload信息失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, fmt.Errorf("%w: %s", errInvalidOrderOptions, err)
 	}
 	
-	orderSignedBody := bytes.NewBuffer([]byte(orderSignedContent.FullSerialize()))
-	
-	fmt.Println("orderPayload: ", orderPayload)
-	// 6. NewOrder
-	orderResponse, orderUrl, _, err := step.NewOrder(directory.NewOrder, orderSignedBody.Bytes())
+	// 3. NewOrder
+	orderResponse, orderUrl, err := orderUseCase.client.NewOrder(ctx, directory.NewOrder, orderPayload, accountKey)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"创建订单失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
-	// 7. 查看订单是否存在
-	existOrder, err := orderUseCase.orderRepo.ExistOrder(c.Request.Context(), orderUrl)
+	// 4. 查看订单是否存在
+	existOrder, err := orderUseCase.orderRepo.ExistOrder(ctx, orderUrl)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"获取订单失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
 	if existOrder {
-		c.JSON(200, gin.H{"errCode": 0, "errMsg": "order already exists"})
-		return
+		return order, orderResponse, errOrderAlreadyExists
 	}
 	
 	identifiersByte, err := json.Marshal(orderResponse.Identifiers)
 	authorizationsByte, err := json.Marshal(orderResponse.Authorizations)
 	
-	var domains []string
-	for _, identifier := range identifiers {
-		domains = append(domains, identifier.Value)
-	}
-	
-	// 8. 生成订单rsa私钥
+	// 5. 生成订单rsa私钥
 	csrPrivateKey, err := generateRsaPrivateKey()
 	if err != nil {
 		orderUseCase.logger.Error(
 			"创建用户，生成RSA PrivateKey失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
 	csrPrivateKeyPem, err := marshalPKCS1PrivateKey(csrPrivateKey)
@@ -207,27 +271,29 @@ func (orderUseCase *OrderUseCase) CreateOrder(c *gin.Context) {
 			"创建用户，序列化RSA PrivateKey失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
-	// 8.1. 生成CSR
+	// 5.1. 生成CSR
 	csr, err := step.GenerateCSR(csrPrivateKey, domains[0], domains, false)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"生成证书CSR失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	csrString := base64.RawURLEncoding.EncodeToString(csr)
 	
-	// 9. 记录数据库
-	orderUuid := uuid.NewString()
-	order := Order{
-		Uuid:           orderUuid,
-		AccountUuid:    req.UserUuid,
+	// CA 未返回 profile 时记录请求的配置
+	if orderResponse.Profile == "" {
+		orderResponse.Profile = options.profile
+	}
+	
+	// 6. 记录数据库
+	order = Order{
+		Uuid:           uuid.NewString(),
+		AccountUuid:    accountUuid,
 		OrderUrl:       orderUrl,
 		Status:         orderResponse.Status,
 		Expires:        orderResponse.Expires,
@@ -239,21 +305,22 @@ func (orderUseCase *OrderUseCase) CreateOrder(c *gin.Context) {
 		PrivateKey:     csrPrivateKeyPem.String(),
 		Csr:            csrString,
 		Certificate:    "",
+		PreferredChain: options.preferredChain,
+		Replaces:       options.replaces,
+		Profile:        orderResponse.Profile,
 		CreateTime:     time.Now().Unix(),
 	}
 	
-	err = orderUseCase.orderRepo.CreateOrder(c.Request.Context(), order)
+	err = orderUseCase.orderRepo.CreateOrder(ctx, order)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"记录订单信息到数据库失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
-		return
+		return order, orderResponse, err
 	}
 	
-	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "order": orderResponse, "orderUrl": orderUrl, "orderUuid": orderUuid})
-	return
+	return order, orderResponse, nil
 }
 
 // 获取订单
@@ -298,7 +365,7 @@ func (orderUseCase *OrderUseCase) GetOrder(c *gin.Context) {
 	}
 	
 	//
-	privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	if err != nil {
 		orderUseCase.logger.Error(
 			"解析用户私钥失败",
@@ -309,68 +376,123 @@ func (orderUseCase *OrderUseCase) GetOrder(c *gin.Context) {
 	}
 	
 	//
-	directory, err := step.Directory(directoryUrl)
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	orderResp, err := orderUseCase.client.GetOrder(c.Request.Context(), order.OrderUrl, accountKey)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Directory失败",
+			"获取Order失败",
 			zap.Error(err),
 		)
-		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		common.ResponseAcmeError(c, err)
 		return
 	}
 	
-	//
-	nonce, err := step.GetNonce(directory.NewNonce)
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "order": orderResp})
+	return
+}
+
+// 列出订单 - 仅数据库列出
+
+type ListOrderReq struct {
+	UserUuid string `json:"userUuid,omitempty" form:"userUuid" validate:"required"`
+}
+
+func (orderUseCase *OrderUseCase) ListOrder(c *gin.Context) {
+	var req ListOrderReq
+	err := common.BindUriQuery(c, &req)
+	if err != nil {
+		return
+	}
+	
+	orders, err := orderUseCase.orderRepo.ListOrder(c.Request.Context(), req.UserUuid)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取 ACME Nonce失败",
+			"获取订单失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	//
-	getOrderContent, err := step.GetSignature(order.OrderUrl, nonce, "", account.Url, privateKey)
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "orders": orders})
+	return
+}
+
+// 列出 CA 中账户的订单, 并与数据库中的订单对比
+// notInDatabase 为 CA 中存在但数据库中不存在的订单 (auto-cert 之外创建或数据库丢失)
+// notInCa 为数据库中存在但 CA 未返回的订单, CA 可以不返回已失效的订单
+
+func (orderUseCase *OrderUseCase) ListAccountOrders(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	
+	// 1. 获取账户
+	account, err := orderUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Signature失败",
+			"获取用户信息失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	getOrderBody := bytes.NewBuffer([]byte(getOrderContent.FullSerialize()))
-	
-	orderResp, _, err := step.GetOrder(order.OrderUrl, getOrderBody.Bytes())
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 	if err != nil {
 		orderUseCase.logger.Error(
-			"获取Order失败",
+			"解析用户私钥失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "order": orderResp})
-	return
-}
-
-// 列出订单 - 仅数据库列出
-
-type ListOrderReq struct {
-	UserUuid string `json:"userUuid,omitempty" form:"userUuid" validate:"required"`
-}
-
-func (orderUseCase *OrderUseCase) ListOrder(c *gin.Context) {
-	var req ListOrderReq
-	err := common.BindUriQuery(c, &req)
+	// 2. 历史账户未记录 orders url 时, 通过空更新 ({}) 获取账户信息
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	ordersUrl := account.OrdersUrl
+	if ordersUrl == "" {
+		accountResp, err := orderUseCase.client.UpdateAccount(c.Request.Context(), "{}", accountKey)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"获取CA账户信息失败",
+				zap.String("userUuid", userUuid),
+				zap.Error(err),
+			)
+			common.ResponseAcmeError(c, err)
+			return
+		}
+		
+		ordersUrl = accountResp.Orders
+		if ordersUrl == "" {
+			c.JSON(400, gin.H{"errCode": 400, "errMsg": "CA 未提供账户订单列表"})
+			return
+		}
+		
+		err = orderUseCase.accountRepo.UpdateAccountOrdersUrl(c.Request.Context(), userUuid, ordersUrl)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"更新账户orders url失败",
+				zap.String("userUuid", userUuid),
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+	}
+	
+	// 3. 获取 CA 订单列表
+	caOrders, err := orderUseCase.client.ListAccountOrders(c.Request.Context(), ordersUrl, accountKey)
 	if err != nil {
+		orderUseCase.logger.Error(
+			"获取CA订单列表失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
 		return
 	}
 	
-	orders, err := orderUseCase.orderRepo.ListOrder(c.Request.Context(), req.UserUuid)
+	// 4. 与数据库订单对比
+	orders, err := orderUseCase.orderRepo.ListOrder(c.Request.Context(), userUuid)
 	if err != nil {
 		orderUseCase.logger.Error(
 			"获取订单失败",
@@ -380,6 +502,27 @@ func (orderUseCase *OrderUseCase) ListOrder(c *gin.Context) {
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "orders": orders})
+	dbOrders := make(map[string]bool)
+	for _, order := range orders {
+		dbOrders[order.OrderUrl] = true
+	}
+	
+	existOrders := make(map[string]bool)
+	notInDatabase := []string{}
+	for _, orderUrl := range caOrders {
+		existOrders[orderUrl] = true
+		if !dbOrders[orderUrl] {
+			notInDatabase = append(notInDatabase, orderUrl)
+		}
+	}
+	
+	notInCa := []Order{}
+	for _, order := range orders {
+		if !existOrders[order.OrderUrl] {
+			notInCa = append(notInCa, order)
+		}
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "caOrders": caOrders, "notInDatabase": notInDatabase, "notInCa": notInCa})
 	return
 }
@@ -0,0 +1,361 @@
+package biz
+
+import (
+	"context"
+	"crypto"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/qx66/auto-cert/internal/biz/common"
+	"github.com/qx66/auto-cert/internal/conf"
+	"github.com/qx66/auto-cert/pkg/step"
+	"go.uber.org/zap"
+	"time"
+)
+
+// 预授权 (newAuthz)
+// 预授权通过后, CA 会在有效期内缓存该域名的 valid authorization, 之后创建的订单无需再次等待 DNS 验证
+
+type Authorization struct {
+	Uuid        string `json:"uuid"`
+	AccountUuid string `json:"accountUuid"`
+	Identifier  string `json:"identifier"` // 域名
+	Url         string `json:"url"`        // authorization url, location
+	Status      string `json:"status"`     // pending/valid/invalid/deactivated/expired/revoked
+	Expires     string `json:"expires"`
+	CreateTime  int64  `json:"createTime"`
+}
+
+func (authorization *Authorization) TableName() string {
+	return "authorization"
+}
+
+type AuthorizationRepo interface {
+	CreateAuthorization(ctx context.Context, authorization Authorization) error
+	GetAuthorization(ctx context.Context, userUuid, authorizationUuid string) (Authorization, error)
+	ListAuthorization(ctx context.Context, userUuid string) ([]Authorization, error)
+	UpdateAuthorizationStatus(ctx context.Context, authorizationUuid, status, expires string) error
+}
+
+type AuthorizationUseCase struct {
+	authorizationRepo AuthorizationRepo
+	accountRepo       AccountRepo
+	client            *step.Client
+	propagation       step.PropagationOptions
+	logger            *zap.Logger
+}
+
+func NewAuthorizationUseCase(authorizationRepo AuthorizationRepo, accountRepo AccountRepo, client *step.Client, dns *conf.Dns, logger *zap.Logger) *AuthorizationUseCase {
+	return &AuthorizationUseCase{
+		authorizationRepo: authorizationRepo,
+		accountRepo:       accountRepo,
+		client:            client,
+		propagation:       newPropagationOptions(dns),
+		logger:            logger,
+	}
+}
+
+// 创建预授权
+
+type CreateAuthorizationReq struct {
+	Domain string `json:"domain" validate:"required"`
+}
+
+func (authorizationUseCase *AuthorizationUseCase) CreateAuthorization(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	var req CreateAuthorizationReq
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	// 1. 获取账户
+	account, err := authorizationUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取用户信息失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"解析用户私钥失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 获取 directory
+	directory, err := authorizationUseCase.client.Directory(c.Request.Context())
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取Directory失败",
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	if directory.NewAuthz == "" {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "CA 不支持预授权 (newAuthz)"})
+		return
+	}
+	
+	// 3. Payload
+	payload, err := step.GenerateNewAuthzPayload(step.NewIdentifier(req.Domain))
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "预授权不支持通配符域名"})
+		return
+	}
+	
+	// 4. 创建预授权
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	authoriz, location, err := authorizationUseCase.client.NewAuthorization(c.Request.Context(), directory.NewAuthz, payload, accountKey)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"创建预授权失败",
+			zap.String("domain", req.Domain),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	// 5. 添加预授权到数据库
+	authorization := Authorization{
+		Uuid:        uuid.NewString(),
+		AccountUuid: userUuid,
+		Identifier:  authoriz.Identifier.Value,
+		Url:         location,
+		Status:      authoriz.Status,
+		Expires:     authoriz.Expires,
+		CreateTime:  time.Now().Unix(),
+	}
+	
+	err = authorizationUseCase.authorizationRepo.CreateAuthorization(c.Request.Context(), authorization)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"添加预授权到数据库失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 6. 返回需要添加的 DNS 记录
+	var replyDnsChallenges []DnsChallenge
+	for _, challenge := range authoriz.Challenges {
+		if challenge.Type != "dns-01" {
+			continue
+		}
+		
+		dnsChallenge, err := getDnsChallenge(authoriz, challenge, privateKey)
+		if err != nil {
+			authorizationUseCase.logger.Error(
+				"生成auth challenge key失败",
+				zap.String("authorization", location),
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+		
+		replyDnsChallenges = append(replyDnsChallenges, dnsChallenge)
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "authorization": authorization, "dnsChallenges": replyDnsChallenges})
+	return
+}
+
+// 获取预授权列表
+
+func (authorizationUseCase *AuthorizationUseCase) ListAuthorization(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	
+	authorizations, err := authorizationUseCase.authorizationRepo.ListAuthorization(c.Request.Context(), userUuid)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取预授权列表失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "authorizations": authorizations})
+	return
+}
+
+// 预授权 Challenge, 与订单 Challenge 相同, 先预检查 TXT 记录, 通过后通知 CA 开始验证
+
+func (authorizationUseCase *AuthorizationUseCase) GetAuthorizationChallenge(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	authorizationUuid := c.Param("authzUuid")
+	
+	// 1. 获取预授权
+	authorization, err := authorizationUseCase.authorizationRepo.GetAuthorization(c.Request.Context(), userUuid, authorizationUuid)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取预授权失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 获取账户
+	account, err := authorizationUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取用户信息失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"解析用户私钥失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 3. 获取 Authorization
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	authoriz, err := authorizationUseCase.client.GetOrderAuthorization(c.Request.Context(), authorization.Url, accountKey)
+	if err != nil {
+		authorizationUseCase.logger.Error(
+			"获取authorization失败",
+			zap.String("authorization", authorization.Url),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	if authoriz.Status != authorization.Status {
+		err = authorizationUseCase.authorizationRepo.UpdateAuthorizationStatus(c.Request.Context(), authorizationUuid, authoriz.Status, authoriz.Expires)
+		if err != nil {
+			authorizationUseCase.logger.Error(
+				"更新预授权状态失败",
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+	}
+	
+	if authoriz.Status != "pending" {
+		c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "authorization": authoriz})
+		return
+	}
+	
+	// 4. 预检查 TXT 记录, 通过后执行 Challenge
+	var replyDnsChallenges []DnsChallenge
+	for _, challenge := range authoriz.Challenges {
+		if challenge.Type != "dns-01" || challenge.Status != "pending" {
+			continue
+		}
+		
+		dnsChallenge, err := getDnsChallenge(authoriz, challenge, privateKey)
+		if err != nil {
+			authorizationUseCase.logger.Error(
+				"生成auth challenge key失败",
+				zap.String("authorization", authorization.Url),
+				zap.Error(err),
+			)
+			c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+			return
+		}
+		
+		report, err := step.CheckTxtPropagation(c.Request.Context(), dnsChallenge.FQDN, []string{dnsChallenge.Value}, authorizationUseCase.propagation)
+		dnsChallenge.Propagation = &report
+		if err != nil {
+			authorizationUseCase.logger.Error(
+				"Authorization Challenge 验证DNS失败",
+				zap.Error(err),
+			)
+			
+			replyDnsChallenges = append(replyDnsChallenges, dnsChallenge)
+			c.JSON(200, gin.H{
+				"errCode":                        0,
+				"errMsg":                         "fail",
+				"authorization":                  authoriz,
+				"dnsChallenges":                  replyDnsChallenges,
+				"preCheckAuthorizationChallenge": false,
+			})
+			return
+		}
+		
+		dnsChallenge.Result = true
+		replyDnsChallenges = append(replyDnsChallenges, dnsChallenge)
+		
+		challenge, err = authorizationUseCase.client.GetOrderAuthorizationChallenge(c.Request.Context(), challenge.Url, accountKey)
+		if err != nil {
+			authorizationUseCase.logger.Error(
+				"获取authorization challenge失败",
+				zap.String("authorization", authorization.Url),
+				zap.Error(err),
+			)
+			common.ResponseAcmeError(c, err)
+			return
+		}
+		
+		authorizationUseCase.logger.Info(
+			"执行预授权 Challenge 成功",
+			zap.String("authorizationUuid", authorizationUuid),
+			zap.String("fqdn", dnsChallenge.FQDN),
+			zap.Any("challenge", challenge),
+		)
+		break
+	}
+	
+	// 没有可执行的 dns-01 challenge 时未进行预检查
+	if len(replyDnsChallenges) == 0 {
+		c.JSON(200, gin.H{
+			"errCode":                        0,
+			"errMsg":                         "没有可执行的 dns-01 challenge",
+			"authorization":                  authoriz,
+			"dnsChallenges":                  replyDnsChallenges,
+			"preCheckAuthorizationChallenge": false,
+		})
+		return
+	}
+	
+	c.JSON(200, gin.H{
+		"errCode":                        0,
+		"errMsg":                         "ok",
+		"authorization":                  authoriz,
+		"dnsChallenges":                  replyDnsChallenges,
+		"preCheckAuthorizationChallenge": true,
+	})
+	return
+}
+
+// 生成 dns-01 challenge 需要添加的 TXT 记录
+
+func getDnsChallenge(authoriz step.Authorization, challenge step.Challenge, privateKey crypto.Signer) (DnsChallenge, error) {
+	authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
+	if err != nil {
+		return DnsChallenge{}, err
+	}
+	
+	fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
+	
+	return DnsChallenge{
+		DomainName: authoriz.Identifier.Value,
+		FQDN:       fqdn,
+		Type:       "TXT",
+		Token:      challenge.Token,
+		Value:      record,
+		Status:     challenge.Status,
+	}, nil
+}
@@ -0,0 +1,194 @@
+package biz
+
+import (
+	"context"
+	"crypto"
+	"github.com/qx66/auto-cert/internal/conf"
+	"github.com/qx66/auto-cert/pkg/step"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// 内置 solver 验证 (http-01/tls-alpn-01), 由 auto-cert 直接响应 CA 的验证请求, 无需修改 DNS 记录
+
+// 验证结束的轮询间隔和最长等待时间, 超时后同样移除 token
+
+const (
+	solverPollInterval   = 3 * time.Second
+	solverCleanUpTimeout = 5 * time.Minute
+)
+
+// 未启用 challenge.http01 时返回 nil
+
+func NewHttp01Solver(challenge *conf.Challenge) *step.Http01Solver {
+	if !challenge.GetHttp01().GetEnabled() {
+		return nil
+	}
+	
+	return step.NewHttp01Solver()
+}
+
+// 未启用 challenge.tlsAlpn01 时返回 nil
+
+func NewTlsAlpn01Solver(challenge *conf.Challenge) *step.TlsAlpn01Solver {
+	if !challenge.GetTlsAlpn01().GetEnabled() {
+		return nil
+	}
+	
+	return step.NewTlsAlpn01Solver()
+}
+
+type SolverChallenge struct {
+	DomainName string `json:"domainName"`
+	Type       string `json:"type"`          // http-01/tls-alpn-01
+	Url        string `json:"url,omitempty"` // http-01 CA 验证时访问的地址
+	Token      string `json:"token"`
+	Status     string `json:"status"`
+}
+
+// 启用内置 solver 时优先使用 http-01, 其次 tls-alpn-01, 通配符域名 CA 只提供 dns-01
+
+func (orderUseCase *OrderUseCase) solverChallenge(authoriz step.Authorization) (step.Challenge, bool) {
+	var challengeTypes []string
+	if orderUseCase.http01Solver != nil {
+		challengeTypes = append(challengeTypes, "http-01")
+	}
+	
+	if orderUseCase.tlsAlpn01Solver != nil {
+		challengeTypes = append(challengeTypes, "tls-alpn-01")
+	}
+	
+	for _, challengeType := range challengeTypes {
+		for _, challenge := range authoriz.Challenges {
+			if challenge.Type == challengeType {
+				return challenge, true
+			}
+		}
+	}
+	
+	return step.Challenge{}, false
+}
+
+// 将 key authorization 添加到对应的 solver
+
+func (orderUseCase *OrderUseCase) presentSolverChallenge(authoriz step.Authorization, challenge step.Challenge, privateKey crypto.Signer) (SolverChallenge, error) {
+	keyAuthorization, err := step.GetKeyAuthorization(challenge.Token, privateKey)
+	if err != nil {
+		return SolverChallenge{}, err
+	}
+	
+	solverChallenge := SolverChallenge{
+		DomainName: authoriz.Identifier.Value,
+		Type:       challenge.Type,
+		Token:      challenge.Token,
+		Status:     challenge.Status,
+	}
+	
+	switch challenge.Type {
+	case "http-01":
+		orderUseCase.http01Solver.Present(challenge.Token, keyAuthorization)
+		
+		host := authoriz.Identifier.Value
+		if authoriz.Identifier.IsIP() && strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		solverChallenge.Url = "http://" + host + step.Http01ChallengePath(challenge.Token)
+	case "tls-alpn-01":
+		err = orderUseCase.tlsAlpn01Solver.Present(authoriz.Identifier, keyAuthorization)
+	}
+	
+	return solverChallenge, err
+}
+
+func (orderUseCase *OrderUseCase) cleanUpSolverChallenge(authoriz step.Authorization, challenge step.Challenge) {
+	switch challenge.Type {
+	case "http-01":
+		orderUseCase.http01Solver.CleanUp(challenge.Token)
+	case "tls-alpn-01":
+		orderUseCase.tlsAlpn01Solver.CleanUp(authoriz.Identifier)
+	}
+}
+
+// 等待 authorization 验证结束后移除 token, 在执行 challenge 后通过 goWait 运行
+
+func (orderUseCase *OrderUseCase) waitSolverChallenge(authorizationUrl string, authoriz step.Authorization, challenge step.Challenge, accountKey step.AccountKey) {
+	defer orderUseCase.cleanUpSolverChallenge(authoriz, challenge)
+	
+	orderUseCase.waitAuthorization(authorizationUrl, challenge.Type, accountKey)
+}
+
+// 在后台运行 wait, 已调用 Shutdown 时直接运行 (waitCtx 已取消, 只执行清理)
+
+func (orderUseCase *OrderUseCase) goWait(wait func()) {
+	orderUseCase.waitMutex.Lock()
+	shutdown := orderUseCase.shutdown
+	if !shutdown {
+		orderUseCase.waitGroup.Add(1)
+	}
+	orderUseCase.waitMutex.Unlock()
+	
+	if shutdown {
+		wait()
+		return
+	}
+	
+	go func() {
+		defer orderUseCase.waitGroup.Done()
+		wait()
+	}()
+}
+
+// 程序退出时取消等待中的 authorization, 并等待移除 token/TXT 记录
+
+func (orderUseCase *OrderUseCase) Shutdown() {
+	orderUseCase.waitMutex.Lock()
+	orderUseCase.shutdown = true
+	orderUseCase.waitMutex.Unlock()
+	
+	orderUseCase.waitCancel()
+	orderUseCase.waitGroup.Wait()
+}
+
+// 轮询 authorization 直到状态不再为 pending, 超时或程序退出
+
+func (orderUseCase *OrderUseCase) waitAuthorization(authorizationUrl string, challengeType string, accountKey step.AccountKey) {
+	ctx, cancel := context.WithTimeout(orderUseCase.waitCtx, solverCleanUpTimeout)
+	defer cancel()
+	
+	ticker := time.NewTicker(solverPollInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			orderUseCase.logger.Info(
+				"等待验证结束超时或程序退出",
+				zap.String("authorization", authorizationUrl),
+				zap.String("type", challengeType),
+			)
+			return
+		case <-ticker.C:
+		}
+		
+		authorizResp, err := orderUseCase.client.GetOrderAuthorization(ctx, authorizationUrl, accountKey)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"获取authorization失败",
+				zap.String("authorization", authorizationUrl),
+				zap.Error(err),
+			)
+			continue
+		}
+		
+		if authorizResp.Status != "pending" {
+			orderUseCase.logger.Info(
+				"验证结束",
+				zap.String("authorization", authorizationUrl),
+				zap.String("type", challengeType),
+				zap.String("status", authorizResp.Status),
+			)
+			return
+		}
+	}
+}
@@ -1,15 +1,82 @@
 package biz
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/qx66/auto-cert/pkg/step"
 	"github.com/startopsz/rule/pkg/ssl"
 	"go.uber.org/zap"
+	"time"
 )
 
+// 查询续期信息或创建续期订单失败且 CA 未返回 Retry-After 时, 推迟该订单的时间
+const renewalRetryAfter = time.Hour
+
+// 订单或账户处于 CA 要求的等待期内 (Retry-After) 时, 定时任务跳过该订单
+
+func waitingNextAttempt(order Order, account Account) bool {
+	now := time.Now().Unix()
+	return order.NextAttemptAt > now || account.NextAttemptAt > now
+}
+
+// CA 返回 Retry-After 或 rateLimited 时记录下次允许请求的时间
+// rateLimited 作用于整个账户, 其余错误 (如 503) 仅作用于当前订单
+// 调用方在 rateLimited 时结束本次任务, 其余错误跳过当前订单继续处理
+
+func (orderUseCase *OrderUseCase) deferNextAttempt(ctx context.Context, order Order, err error) {
+	retryAfter, ok := step.RetryAfter(err)
+	if !ok {
+		return
+	}
+	
+	nextAttemptAt := time.Now().Add(retryAfter).Unix()
+	
+	if step.IsRateLimited(err) {
+		updateErr := orderUseCase.accountRepo.UpdateAccountNextAttemptAt(ctx, order.AccountUuid, nextAttemptAt)
+		if updateErr != nil {
+			orderUseCase.logger.Error(
+				"更新账户下次请求时间失败",
+				zap.String("accountUuid", order.AccountUuid),
+				zap.Error(updateErr),
+			)
+		}
+	}
+	
+	updateErr := orderUseCase.orderRepo.UpdateOrderNextAttemptAt(ctx, order.Uuid, nextAttemptAt)
+	if updateErr != nil {
+		orderUseCase.logger.Error(
+			"更新订单下次请求时间失败",
+			zap.String("orderUuid", order.Uuid),
+			zap.Error(updateErr),
+		)
+		return
+	}
+	
+	orderUseCase.logger.Info(
+		"CA要求延迟重试, 跳过订单直到下次请求时间",
+		zap.String("orderUuid", order.Uuid),
+		zap.Duration("retryAfter", retryAfter),
+		zap.Int64("nextAttemptAt", nextAttemptAt),
+	)
+}
+
+// 续期订单失败 (invalid) 时清空原订单的 replaced_by, 原订单会在下次定时任务中重新续期
+
+func (orderUseCase *OrderUseCase) releaseReplacedOrder(ctx context.Context, order Order, status string) {
+	if status != "invalid" {
+		return
+	}
+	
+	err := orderUseCase.orderRepo.ClearOrderReplacedBy(ctx, order.Uuid)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"清空原订单续期订单失败",
+			zap.String("orderUuid", order.Uuid),
+			zap.Error(err),
+		)
+	}
+}
+
 // Task 获取状态为 Pending 状态的订单
 
 func (orderUseCase *OrderUseCase) GetPendingStatusOrder(ctx context.Context) {
@@ -42,57 +109,33 @@ func (orderUseCase *OrderUseCase) GetPendingStatusOrder(ctx context.Context) {
 			break
 		}
 		
-		privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
-		if err != nil {
-			orderUseCase.logger.Error(
-				"解析用户私钥失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
+		if waitingNextAttempt(order, account) {
+			continue
 		}
 		
-		// 2.2. 获取 directory
-		directory, err := step.Directory(directoryUrl)
+		privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Directory失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
-		}
-		
-		// 2.3. 获取 nonce
-		nonce, err := step.GetNonce(directory.NewNonce)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取 ACME Nonce失败",
+				"解析用户私钥失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		// 2.4. 获取订单信息
-		getOrderContent, err := step.GetSignature(order.OrderUrl, nonce, "", account.Url, privateKey)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取Signature失败",
-				zap.Error(err),
-			)
-			break
-		}
-		
-		getOrderBody := bytes.NewBuffer([]byte(getOrderContent.FullSerialize()))
-		
-		orderResp, nonce, err := step.GetOrder(order.OrderUrl, getOrderBody.Bytes())
+		// 2.2. 获取订单信息
+		accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+		orderResp, err := orderUseCase.client.GetOrder(ctx, order.OrderUrl, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
 				"获取Order失败",
 				zap.Error(err),
 			)
-			break
+			orderUseCase.deferNextAttempt(ctx, order, err)
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
 		}
 		
 		if orderResp.Status != "pending" {
@@ -104,10 +147,15 @@ func (orderUseCase *OrderUseCase) GetPendingStatusOrder(ctx context.Context) {
 					zap.String("orderUuid", order.Uuid),
 				)
 			}
+			orderUseCase.releaseReplacedOrder(ctx, order, orderResp.Status)
+			
+			if orderResp.Status == "invalid" {
+				orderUseCase.cleanUpAbandonedDnsChallenges(ctx, order, account, accountKey)
+			}
 			break
 		}
 		
-		// 2.4. 反序列化 authorizations
+		// 2.3. 反序列化 authorizations
 		var authorizations []string
 		err = json.Unmarshal(order.Authorizations, &authorizations)
 		if err != nil {
@@ -119,118 +167,218 @@ func (orderUseCase *OrderUseCase) GetPendingStatusOrder(ctx context.Context) {
 			break
 		}
 		
-		// 2.5. nonce
-		replayNonce := &nonce
-		
-		// 2.6. 循环 authorizations
+		// 2.4. 循环 authorizations, 未完整获取时跳过 2.5, 避免只添加部分 TXT 记录
+		var dnsChallengeGroups []*dnsChallengeGroup
+		collected := true
+		rateLimited := false
 		for _, authorization := range authorizations {
 			
-			// 2.6.1. authorizations GetSignature
-			getOrderAuthorizationContent, err := step.GetSignature(authorization, *replayNonce, "", account.Url, privateKey)
+			// 2.4.1. GetOrderAuthorization
+			authoriz, err := orderUseCase.client.GetOrderAuthorization(ctx, authorization, accountKey)
 			if err != nil {
 				orderUseCase.logger.Error(
-					"获取Signature失败",
+					"获取authorization失败",
 					zap.String("authorization", authorization),
 					zap.String("orderUuid", order.Uuid),
 					zap.Error(err),
 				)
+				orderUseCase.deferNextAttempt(ctx, order, err)
+				rateLimited = step.IsRateLimited(err)
+				collected = false
 				break
 			}
 			
-			getOrderAuthorizationBody := bytes.NewBuffer([]byte(getOrderAuthorizationContent.FullSerialize()))
+			// 通配符域名与主域名的 authorization 可能其中一个已通过验证
+			if authoriz.Status == "valid" {
+				continue
+			}
 			
-			// 2.6.2. GetOrderAuthorization
-			authoriz, nonce, err := step.GetOrderAuthorization(authorization, getOrderAuthorizationBody.Bytes())
-			if err != nil {
-				orderUseCase.logger.Error(
-					"获取authorization失败",
+			if authoriz.Status != "pending" {
+				orderUseCase.logger.Info(
+					"订单authorization状态不匹配",
 					zap.String("authorization", authorization),
+					zap.String("status", authoriz.Status),
 					zap.String("orderUuid", order.Uuid),
-					zap.Error(err),
 				)
+				collected = false
 				break
 			}
 			
-			replayNonce = &nonce
-			if authoriz.Status != "pending" {
+			// http-01/tls-alpn-01 由内置 solver 响应, 执行 Challenge 后等待验证结束并移除 token
+			if challenge, ok := orderUseCase.solverChallenge(authoriz); ok {
+				if challenge.Status != "pending" {
+					continue
+				}
+				
+				_, err = orderUseCase.presentSolverChallenge(authoriz, challenge, privateKey)
+				if err != nil {
+					orderUseCase.logger.Error(
+						"生成auth challenge key失败",
+						zap.String("authorization", authorization),
+						zap.String("orderUuid", order.Uuid),
+						zap.Error(err),
+					)
+					collected = false
+					break
+				}
+				
+				challengeResp, err := orderUseCase.client.GetOrderAuthorizationChallenge(ctx, challenge.Url, accountKey)
+				if err != nil {
+					orderUseCase.logger.Error(
+						"获取authorization challenge失败",
+						zap.String("authorization", authorization),
+						zap.String("orderUuid", order.Uuid),
+						zap.Error(err),
+					)
+					orderUseCase.cleanUpSolverChallenge(authoriz, challenge)
+					orderUseCase.deferNextAttempt(ctx, order, err)
+					rateLimited = step.IsRateLimited(err)
+					collected = false
+					break
+				}
+				
+				authorization := authorization
+				orderUseCase.goWait(func() {
+					orderUseCase.waitSolverChallenge(authorization, authoriz, challenge, accountKey)
+				})
+				
 				orderUseCase.logger.Info(
-					"订单authorization状态不匹配",
+					"执行 authorization Challenge 成功",
+					zap.String("orderUuid", order.Uuid),
 					zap.String("authorization", authorization),
-					zap.String("status", authoriz.Status),
+					zap.String("status", challengeResp.Status),
+				)
+				continue
+			}
+			
+			// ip 标识不提供 dns-01 验证
+			if authoriz.Identifier.IsIP() {
+				orderUseCase.logger.Info(
+					"IP标识需要使用 http-01 或 tls-alpn-01 验证",
+					zap.String("identifier", authoriz.Identifier.Value),
 					zap.String("orderUuid", order.Uuid),
 				)
-				break
+				continue
 			}
 			
-			// 2.6.3. GetOrderAuthorization Challenges
+			// 2.4.2. GetOrderAuthorization Challenges, 按 FQDN 分组
 			for _, challenge := range authoriz.Challenges {
+				if challenge.Type != "dns-01" || challenge.Status != "pending" {
+					continue
+				}
+				
+				authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
+				if err != nil {
+					orderUseCase.logger.Error(
+						"生成auth challenge key失败",
+						zap.String("authorization", authorization),
+						zap.String("orderUuid", order.Uuid),
+						zap.Error(err),
+					)
+					collected = false
+					break
+				}
+				
+				fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
+				dnsChallengeGroups = addDnsChallenge(dnsChallengeGroups, fqdn, record,
+					pendingChallenge{authorization: authorization, authoriz: authoriz, challenge: challenge})
+			}
+		}
+		
+		if rateLimited {
+			break
+		}
+		
+		if !collected {
+			continue
+		}
+		
+		// 2.5. 循环 FQDN
+		for _, group := range dnsChallengeGroups {
+			
+			// 2.5.1. 配置 DNSProvider 时自动添加 TXT 记录, 域名后缀匹配的 provider 优先于账户指定的 provider
+			propagation := orderUseCase.propagation
+			providerName, provider, withProvider := orderUseCase.dnsProviders.Lookup(group.challenges[0].authoriz.Identifier.Value, account.DnsProvider)
+			var providerFqdn string
+			if withProvider {
+				providerFqdn, err = orderUseCase.presentDnsChallenge(ctx, provider, group)
+				if err != nil {
+					orderUseCase.logger.Error(
+						"DNSProvider 添加TXT记录失败",
+						zap.String("orderUuid", order.Uuid),
+						zap.String("dnsProvider", providerName),
+						zap.String("fqdn", group.fqdn),
+						zap.Error(err),
+					)
+					continue
+				}
 				
-				if challenge.Type == "dns-01" {
-					fqdn := fmt.Sprintf("_acme-challenge.%s", authoriz.Identifier.Value)
-					// 2.6.3.1 Get Order Authorization DNS auth
-					authKey, err := step.GetKeyAuthorization(challenge.Token, privateKey)
-					if err != nil {
-						orderUseCase.logger.Error(
-							"生成auth challenge key失败",
-							zap.String("authorization", authorization),
-							zap.String("orderUuid", order.Uuid),
-							zap.Error(err),
-						)
-						break
-					}
-					
-					fqdn, record := step.GetRecord(authoriz.Identifier.Value, authKey)
-					
-					// 2.6.3.2 VerifyTxtRecord
-					err = step.VerifyTxtRecord(fqdn, record, orderUseCase.dns)
-					if err != nil {
-						orderUseCase.logger.Error(
-							"Order Authorization Challenge 验证DNS失败",
-							zap.String("orderUuid", order.Uuid),
-							zap.String("fqdn", fqdn),
-							zap.String("value", record),
-							zap.Strings("dns", orderUseCase.dns),
-							zap.Error(err),
-						)
-						break
-					}
-					
-					orderUseCase.logger.Info(
-						"Order Authorization Challenge 本地验证DNS成功",
+				propagation = orderUseCase.dnsProviderPropagation(provider)
+			}
+			
+			// 2.5.2. 等待同一 FQDN 的全部 TXT 值在全部权威服务器生效
+			// 未生效时保留 DNSProvider 添加的记录, 下次定时任务重新检查, 记录在验证结束或订单失效后删除
+			report, err := step.CheckTxtPropagation(ctx, group.fqdn, group.values, propagation)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"Order Authorization Challenge 验证DNS失败",
+					zap.String("orderUuid", order.Uuid),
+					zap.String("fqdn", group.fqdn),
+					zap.Strings("values", group.values),
+					zap.Any("nameservers", report.Nameservers),
+					zap.Error(err),
+				)
+				continue
+			}
+			
+			orderUseCase.logger.Info(
+				"Order Authorization Challenge 本地验证DNS成功",
+				zap.String("orderUuid", order.Uuid),
+				zap.String("fqdn", group.fqdn),
+				zap.Strings("values", group.values),
+			)
+			
+			// 2.5.3. GetOrderAuthorizationChallenge
+			var triggered []pendingChallenge
+			var triggeredValues []string
+			for i, pending := range group.challenges {
+				challenge, err := orderUseCase.client.GetOrderAuthorizationChallenge(ctx, pending.challenge.Url, accountKey)
+				if err != nil {
+					orderUseCase.logger.Error(
+						"获取authorization challenge失败",
+						zap.String("authorization", pending.authorization),
 						zap.String("orderUuid", order.Uuid),
-						zap.String("fqdn", fqdn),
-						zap.String("value", record),
+						zap.Error(err),
 					)
-					// 2.6.3.3 GetSignature
-					getOrderAuthorizationChallengeContent, err := step.GetSignature(challenge.Url, *replayNonce, "{}", account.Url, privateKey)
-					
-					if err != nil {
-						orderUseCase.logger.Error(
-							"获取Signature失败",
-							zap.String("authorization", authorization),
-							zap.String("orderUuid", order.Uuid),
-							zap.Error(err),
-						)
-						break
-					}
-					
-					getOrderAuthorizationChallengeBody := bytes.NewBuffer([]byte(getOrderAuthorizationChallengeContent.FullSerialize()))
-					
-					// 2.6.3.4 GetOrderAuthorizationChallenge
-					challenge, nonce, err := step.GetOrderAuthorizationChallenge(challenge.Url, getOrderAuthorizationChallengeBody.Bytes())
-					if err != nil {
-						orderUseCase.logger.Error(
-							"获取authorization challenge失败",
-							zap.String("authorization", authorization),
-							zap.String("orderUuid", order.Uuid),
-							zap.Error(err),
-						)
-						break
-					}
-					fmt.Println("challenge: ", challenge)
-					replayNonce = &nonce
+					orderUseCase.deferNextAttempt(ctx, order, err)
+					rateLimited = step.IsRateLimited(err)
+					break
 				}
+				triggered = append(triggered, pending)
+				triggeredValues = append(triggeredValues, group.values[i])
+				
+				orderUseCase.logger.Info(
+					"执行 authorization Challenge 成功",
+					zap.String("orderUuid", order.Uuid),
+					zap.String("authorization", pending.authorization),
+					zap.String("status", challenge.Status),
+				)
+			}
+			
+			// 2.5.4. 验证结束后删除已执行 challenge 的 TXT 记录, 未执行的记录保留到下次定时任务
+			if withProvider && len(triggered) > 0 {
+				orderUseCase.goWait(func() {
+					orderUseCase.waitDnsChallenge(provider, providerFqdn, triggeredValues, triggered, accountKey)
+				})
 			}
+			
+			if rateLimited {
+				break
+			}
+		}
+		
+		if rateLimited {
+			break
 		}
 	}
 }
@@ -267,59 +415,34 @@ func (orderUseCase *OrderUseCase) GetReadyStatusOrder(ctx context.Context) {
 			break
 		}
 		
-		privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
-		if err != nil {
-			orderUseCase.logger.Error(
-				"解析用户私钥失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
-		}
-		
-		// 2.2. 获取 directory
-		directory, err := step.Directory(directoryUrl)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取Directory失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
-		}
-		
-		// 2.3. 获取 nonce
-		nonce, err := step.GetNonce(directory.NewNonce)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"获取nonce失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
+		if waitingNextAttempt(order, account) {
+			continue
 		}
 		
-		// 2.4. 获取订单信息
-		getOrderContent, err := step.GetSignature(order.OrderUrl, nonce, "", account.Url, privateKey)
+		privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Signature失败",
+				"解析用户私钥失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		getOrderBody := bytes.NewBuffer([]byte(getOrderContent.FullSerialize()))
-		
-		orderResp, nonce, err := step.GetOrder(order.OrderUrl, getOrderBody.Bytes())
+		// 2.2. 获取订单信息
+		accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+		orderResp, err := orderUseCase.client.GetOrder(ctx, order.OrderUrl, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
 				"获取Order失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
-			break
+			orderUseCase.deferNextAttempt(ctx, order, err)
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
 		}
 		
 		if orderResp.Status != "ready" {
@@ -331,10 +454,11 @@ func (orderUseCase *OrderUseCase) GetReadyStatusOrder(ctx context.Context) {
 					zap.Error(err),
 				)
 			}
+			orderUseCase.releaseReplacedOrder(ctx, order, orderResp.Status)
 			break
 		}
 		
-		// 2.5. 获取 Finalize Payload
+		// 2.3. 获取 Finalize Payload
 		finalizeOrderPayload, err := step.GenerateFinalizeOrderPayload(order.Csr)
 		if err != nil {
 			orderUseCase.logger.Error(
@@ -345,28 +469,19 @@ func (orderUseCase *OrderUseCase) GetReadyStatusOrder(ctx context.Context) {
 			break
 		}
 		
-		// 2.6. 获取 Signature
-		finalizeOrderContent, err := step.GetSignature(order.Finalize, nonce, finalizeOrderPayload, account.Url, privateKey)
-		if err != nil {
-			orderUseCase.logger.Error(
-				"GetSignature失败",
-				zap.String("orderUuid", order.Uuid),
-				zap.Error(err),
-			)
-			break
-		}
-		
-		finalizeOrderBody := bytes.NewBuffer([]byte(finalizeOrderContent.FullSerialize()))
-		
-		// 2.7. Finalize Order
-		finalizeOrder, err := step.FinalizeOrder(order.Finalize, finalizeOrderBody.Bytes())
+		// 2.4. Finalize Order
+		finalizeOrder, err := orderUseCase.client.FinalizeOrder(ctx, order.Finalize, finalizeOrderPayload, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
 				"FinalizeOrder失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
-			break
+			orderUseCase.deferNextAttempt(ctx, order, err)
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
 		}
 		
 		orderUseCase.logger.Info(
@@ -374,10 +489,10 @@ func (orderUseCase *OrderUseCase) GetReadyStatusOrder(ctx context.Context) {
 			zap.String("orderUuid", order.Uuid),
 			zap.String("finalize", finalizeOrder.Finalize),
 			zap.String("status", finalizeOrder.Status),
-		
+			
 		)
 		
-		// 2.8
+		// 2.5
 		err = orderUseCase.orderRepo.UpdateOrderStatus(ctx, order.Uuid, finalizeOrder.Status)
 		if err != nil {
 			orderUseCase.logger.Error(
@@ -386,6 +501,7 @@ func (orderUseCase *OrderUseCase) GetReadyStatusOrder(ctx context.Context) {
 				zap.Error(err),
 			)
 		}
+		orderUseCase.releaseReplacedOrder(ctx, order, finalizeOrder.Status)
 	}
 }
 
@@ -415,7 +531,11 @@ func (orderUseCase *OrderUseCase) GetNotCertificateOrder(ctx context.Context) {
 			break
 		}
 		
-		privateKey, err := parsePKCS1PrivateKey([]byte(account.PrivateKey))
+		if waitingNextAttempt(order, account) {
+			continue
+		}
+		
+		privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 		
 		if err != nil {
 			orderUseCase.logger.Error(
@@ -426,93 +546,316 @@ func (orderUseCase *OrderUseCase) GetNotCertificateOrder(ctx context.Context) {
 			break
 		}
 		
-		// 2.2. 获取 directory
-		directory, err := step.Directory(directoryUrl)
+		// 2.2. 获取订单
+		accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+		orderResp, err := orderUseCase.client.GetOrder(ctx, order.OrderUrl, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Directory失败",
+				"获取Order失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
-			break
+			orderUseCase.deferNextAttempt(ctx, order, err)
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
 		}
 		
-		// 2.3. 获取 nonce
-		nonce, err := step.GetNonce(directory.NewNonce)
+		// 2.3. 获取订单证书
+		certificate, err := orderUseCase.downloadCertificate(ctx, order, orderResp.Certificate, accountKey)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Nonce失败",
+				"获取证书失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
-			break
+			orderUseCase.deferNextAttempt(ctx, order, err)
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
 		}
 		
-		// 2.4. 获取 Signature
-		getOrderContent, err := step.GetSignature(order.OrderUrl, nonce, "", account.Url, privateKey)
+		// 2.4. 更新订单证书数据库信息
+		cert, err := ssl.ParseSSLCertificate([]byte(certificate))
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Signature失败",
+				"解析证书失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		getOrderBody := bytes.NewBuffer([]byte(getOrderContent.FullSerialize()))
+		err = orderUseCase.orderRepo.UpdateOrderCertificate(ctx, order.Uuid, certificate,
+			cert.NotBefore.String(), cert.NotAfter.String())
 		
-		// 2.5. 获取订单
-		orderResp, nonce, err := step.GetOrder(order.OrderUrl, getOrderBody.Bytes())
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Order失败",
+				"更新订单证书失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		// 2.6. 获取 Signature
-		downloadCertificateContent, err := step.GetSignature(orderResp.Certificate, nonce, "", account.Url, privateKey)
+		orderUseCase.logger.Info(
+			"更新订单证书成功",
+			zap.String("orderUuid", order.Uuid),
+		)
+	}
+}
+
+// 查询已签发证书的续期信息 (ARI), 在建议窗口内安排续期时间
+// CA 不支持 ARI 时使用证书有效期计算默认窗口
+
+func (orderUseCase *OrderUseCase) CheckRenewalInfo(ctx context.Context) {
+	now := time.Now()
+	
+	// 1. 列出需要查询续期信息的订单
+	orders, err := orderUseCase.orderRepo.ListRenewalCheckOrder(ctx, now.Unix())
+	if err != nil {
+		orderUseCase.logger.Error(
+			"列出需要查询续期信息的订单失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	if len(orders) == 0 {
+		return
+	}
+	
+	// 2. 获取 directory
+	directory, err := orderUseCase.client.Directory(ctx)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"获取Directory失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	// 3. 循环订单
+	for _, order := range orders {
+		
+		// 3.1. 解析证书
+		cert, err := step.ParseLeafCertificate(order.Certificate)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"解析证书失败",
+				zap.String("orderUuid", order.Uuid),
+				zap.Error(err),
+			)
+			continue
+		}
+		
+		// 3.2. 获取续期信息
+		renewalInfo := step.DefaultRenewalInfo(cert)
+		if directory.RenewalInfo != "" {
+			certID, err := step.CertificateID(cert)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"生成证书ARI标识失败",
+					zap.String("orderUuid", order.Uuid),
+					zap.Error(err),
+				)
+				continue
+			}
+			
+			renewalInfo, err = orderUseCase.client.GetRenewalInfo(ctx, directory.RenewalInfo, certID)
+			if err != nil {
+				orderUseCase.logger.Error(
+					"获取证书续期信息失败",
+					zap.String("orderUuid", order.Uuid),
+					zap.Error(err),
+				)
+				
+				// 推迟下次查询的时间, 避免持续失败 (如 CA 返回 404) 时每次定时任务重复查询
+				retryAfter, ok := step.RetryAfter(err)
+				if !ok {
+					retryAfter = renewalRetryAfter
+				}
+				
+				err = orderUseCase.orderRepo.UpdateOrderRenewal(ctx, order.Uuid, order.RenewalAt, now.Add(retryAfter).Unix())
+				if err != nil {
+					orderUseCase.logger.Error(
+						"更新订单续期时间失败",
+						zap.String("orderUuid", order.Uuid),
+						zap.Error(err),
+					)
+				}
+				continue
+			}
+			
+			if renewalInfo.ExplanationURL != "" {
+				orderUseCase.logger.Info(
+					"CA提供了续期窗口说明",
+					zap.String("orderUuid", order.Uuid),
+					zap.String("explanationURL", renewalInfo.ExplanationURL),
+				)
+			}
+		}
+		
+		// 3.3. 已安排的续期时间仍在窗口内时保持不变, 否则在窗口内重新随机选择
+		renewalAt := order.RenewalAt
+		window := renewalInfo.SuggestedWindow
+		if renewalAt < window.Start.Unix() || renewalAt > window.End.Unix() {
+			renewalAt = renewalInfo.RandomTime().Unix()
+		}
+		
+		renewalCheckAt := now.Add(renewalInfo.RetryAfter).Unix()
+		err = orderUseCase.orderRepo.UpdateOrderRenewal(ctx, order.Uuid, renewalAt, renewalCheckAt)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取Signature失败",
+				"更新订单续期时间失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		downloadCertificateBody := bytes.NewBuffer([]byte(downloadCertificateContent.FullSerialize()))
+		if renewalAt != order.RenewalAt {
+			orderUseCase.logger.Info(
+				"安排订单续期时间",
+				zap.String("orderUuid", order.Uuid),
+				zap.Time("renewalAt", time.Unix(renewalAt, 0)),
+			)
+		}
+	}
+}
+
+// 到达续期时间的订单, 使用相同域名创建新订单, CA 支持 ARI 时通过 replaces 标明被替换的证书
+
+func (orderUseCase *OrderUseCase) RenewOrder(ctx context.Context) {
+	// 1. 列出到达续期时间的订单
+	orders, err := orderUseCase.orderRepo.ListRenewalOrder(ctx, time.Now().Unix())
+	if err != nil {
+		orderUseCase.logger.Error(
+			"列出需要续期的订单失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	if len(orders) == 0 {
+		return
+	}
+	
+	// 2. 获取 directory
+	directory, err := orderUseCase.client.Directory(ctx)
+	if err != nil {
+		orderUseCase.logger.Error(
+			"获取Directory失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	// 3. 循环订单
+	for _, order := range orders {
 		
-		// 8. 获取订单证书
-		certificate, err := step.DownloadCertificate(orderResp.Certificate, downloadCertificateBody.Bytes())
+		// 3.1. 获取账户
+		account, err := orderUseCase.accountRepo.GetAccount(ctx, order.AccountUuid)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"获取证书失败",
+				"获取用户信息失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		// 9. 更新订单证书数据库信息
-		cert, err := ssl.ParseSSLCertificate([]byte(certificate))
+		if waitingNextAttempt(order, account) {
+			continue
+		}
+		
+		privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
 		if err != nil {
 			orderUseCase.logger.Error(
-				"解析证书失败",
+				"解析用户私钥失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
 			break
 		}
 		
-		err = orderUseCase.orderRepo.UpdateOrderCertificate(ctx, order.Uuid, certificate,
-			cert.NotBefore.String(), cert.NotAfter.String())
+		// 3.2. 订单域名
+		var identifiers []step.Identifier
+		err = json.Unmarshal(order.Identifiers, &identifiers)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"解析订单域名失败",
+				zap.String("orderUuid", order.Uuid),
+				zap.Error(err),
+			)
+			continue
+		}
+		
+		var domains []string
+		for _, identifier := range identifiers {
+			domains = append(domains, identifier.Value)
+		}
+		
+		// 3.3. 续期沿用原订单的证书配置, CA 已不再提供该配置时使用默认配置
+		options := newOrderOptions{preferredChain: order.PreferredChain}
+		if order.Profile != "" && directory.SupportsProfile(order.Profile) {
+			options.profile = order.Profile
+		}
+		
+		// 被替换证书的 ARI 标识
+		if directory.RenewalInfo != "" {
+			cert, err := step.ParseLeafCertificate(order.Certificate)
+			if err == nil {
+				options.replaces, err = step.CertificateID(cert)
+			}
+			
+			if err != nil {
+				orderUseCase.logger.Error(
+					"生成证书ARI标识失败",
+					zap.String("orderUuid", order.Uuid),
+					zap.Error(err),
+				)
+				continue
+			}
+		}
 		
+		// 3.4. 创建续期订单
+		accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+		renewalOrder, _, err := orderUseCase.newOrder(ctx, order.AccountUuid, accountKey, domains, options)
 		if err != nil {
 			orderUseCase.logger.Error(
-				"更新订单证书失败",
+				"创建续期订单失败",
+				zap.String("orderUuid", order.Uuid),
+				zap.Error(err),
+			)
+			
+			// CA 未返回 Retry-After 时 (如 rejectedIdentifier/caa) 同样推迟该订单, 避免每次定时任务重复创建
+			if _, ok := step.RetryAfter(err); ok {
+				orderUseCase.deferNextAttempt(ctx, order, err)
+			} else {
+				err := orderUseCase.orderRepo.UpdateOrderNextAttemptAt(ctx, order.Uuid, time.Now().Add(renewalRetryAfter).Unix())
+				if err != nil {
+					orderUseCase.logger.Error(
+						"更新订单下次请求时间失败",
+						zap.String("orderUuid", order.Uuid),
+						zap.Error(err),
+					)
+				}
+			}
+			
+			if step.IsRateLimited(err) {
+				break
+			}
+			continue
+		}
+		
+		// 3.5. 记录续期订单, 避免重复续期; 续期订单失败时由 releaseReplacedOrder 清空
+		err = orderUseCase.orderRepo.UpdateOrderReplacedBy(ctx, order.Uuid, renewalOrder.Uuid)
+		if err != nil {
+			orderUseCase.logger.Error(
+				"更新订单续期订单失败",
 				zap.String("orderUuid", order.Uuid),
 				zap.Error(err),
 			)
@@ -520,8 +863,9 @@ func (orderUseCase *OrderUseCase) GetNotCertificateOrder(ctx context.Context) {
 		}
 		
 		orderUseCase.logger.Info(
-			"更新订单证书成功",
+			"创建续期订单成功",
 			zap.String("orderUuid", order.Uuid),
+			zap.String("renewalOrderUuid", renewalOrder.Uuid),
 		)
 	}
 }
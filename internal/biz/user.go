@@ -3,6 +3,7 @@ package biz
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -20,8 +21,13 @@ import (
 type AccountRepo interface {
 	CreateAccount(ctx context.Context, account Account) error
 	GetAccount(ctx context.Context, uuid string) (Account, error)
-	DelAccount(ctx context.Context, uuid string) error
 	ExistAccount(ctx context.Context, uuid string) (bool, error)
+	UpdateAccountNextAttemptAt(ctx context.Context, uuid string, nextAttemptAt int64) error
+	UpdateAccountPrivateKey(ctx context.Context, uuid string, privateKey string) error
+	UpdateAccountContact(ctx context.Context, uuid string, contact string) error
+	UpdateAccountStatus(ctx context.Context, uuid string, status string) error
+	UpdateAccountOrdersUrl(ctx context.Context, uuid string, ordersUrl string) error
+	UpdateAccountDnsProvider(ctx context.Context, uuid string, dnsProvider string) error
 }
 
 type Account struct {
@@ -31,6 +37,9 @@ type Account struct {
 	PrivateKey           string `json:"privateKey"`
 	Status               string `json:"status"`
 	Url                  string `json:"url"`
+	OrdersUrl            string `json:"ordersUrl"`     // 账户订单列表 url
+	NextAttemptAt        int64  `json:"nextAttemptAt"` // 触发 CA 限流后允许再次请求的时间
+	DnsProvider          string `json:"dnsProvider"`   // 未匹配域名后缀时使用的 DNSProvider
 	CreateTime           int64  `json:"createTime"`
 }
 
@@ -39,22 +48,38 @@ func (account *Account) TableName() string {
 }
 
 type AccountUseCase struct {
-	accountRepo AccountRepo
-	logger      *zap.Logger
+	accountRepo  AccountRepo
+	client       *step.Client
+	dnsProviders *step.DNSProviders
+	logger       *zap.Logger
 }
 
-func NewAccountUseCase(accountRepo AccountRepo, logger *zap.Logger) *AccountUseCase {
+func NewAccountUseCase(accountRepo AccountRepo, client *step.Client, dnsProviders *step.DNSProviders, logger *zap.Logger) *AccountUseCase {
 	return &AccountUseCase{
-		accountRepo: accountRepo,
-		logger:      logger,
+		accountRepo:  accountRepo,
+		client:       client,
+		dnsProviders: dnsProviders,
+		logger:       logger,
 	}
 }
 
+// KeyType 为空时使用 RSA4096
+// EabKid/EabHmacKey 为 CA (ZeroSSL、Google Trust Services 等) 提供的 External Account Binding 凭证, HmacKey 为 base64url 编码
+// DnsProvider 为 dns.providers 中的名称, 定时任务通过该 provider 自动添加 TXT 记录
+
 type CreateAccountReq struct {
-	UserUuid string   `json:"userUuid,omitempty" validate:"required"`
-	Contact  []string `json:"contact" validate:"required"`
+	UserUuid    string   `json:"userUuid,omitempty" validate:"required"`
+	Contact     []string `json:"contact" validate:"required"`
+	KeyType     string   `json:"keyType,omitempty" validate:"omitempty,oneof=RSA2048 RSA3072 RSA4096 EC256 EC384"`
+	EabKid      string   `json:"eabKid,omitempty" validate:"required_with=EabHmacKey"`
+	EabHmacKey  string   `json:"eabHmacKey,omitempty" validate:"required_with=EabKid"`
+	DnsProvider string   `json:"dnsProvider,omitempty"`
 }
 
+const defaultAccountKeyType = step.KeyTypeRSA4096
+
+const accountStatusDeactivated = "deactivated"
+
 // 创建用户
 
 func (accountUseCase *AccountUseCase) CreateAccount(c *gin.Context) {
@@ -77,35 +102,67 @@ func (accountUseCase *AccountUseCase) CreateAccount(c *gin.Context) {
 		return
 	}
 	
+	if _, ok := accountUseCase.dnsProviders.Get(req.DnsProvider); req.DnsProvider != "" && !ok {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "dnsProvider 不存在"})
+		return
+	}
+	
 	// 2. 获取 Directory
-	directory, err := step.Directory(directoryUrl)
+	directory, err := accountUseCase.client.Directory(c.Request.Context())
 	if err != nil {
 		accountUseCase.logger.Error(
 			"创建用户，访问Directory失败",
 			zap.Error(err),
 		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	if directory.Meta.ExternalAccountRequired && req.EabKid == "" {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "CA 要求 External Account Binding, 请提供 eabKid 和 eabHmacKey"})
+		return
+	}
+	
+	// 3. 生成用户私钥
+	keyType := step.KeyType(req.KeyType)
+	if keyType == "" {
+		keyType = defaultAccountKeyType
+	}
+	
+	privateKey, err := step.GeneratePrivateKey(keyType)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"生成PrivateKey失败",
+			zap.String("keyType", string(keyType)),
+			zap.Error(err),
+		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	// 3. 获取 Nonce
-	nonce, err := step.GetNonce(directory.NewNonce)
+	buf, err := marshalPrivateKey(privateKey)
 	if err != nil {
 		accountUseCase.logger.Error(
-			"创建用户，获取 ACME Nonce失败",
+			"创建用户，序列化PrivateKey失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	// 4. 生成 Payload
+	// 4. 生成 Payload, 提供 EAB 凭证时使用 hmac key 对账户公钥签名
 	var contact []string
 	for _, c := range req.Contact {
 		contact = append(contact, fmt.Sprintf("mailto:%s", c))
 	}
 	
-	payload, err := step.GenerateAccountPayload(contact, true, false)
+	var payload string
+	if req.EabKid != "" {
+		payload, err = step.GenerateExternalAccountPayload(directory.NewAccount, contact, true, req.EabKid, req.EabHmacKey, privateKey)
+	} else {
+		payload, err = step.GenerateAccountPayload(contact, true, false)
+	}
+	
 	if err != nil {
 		accountUseCase.logger.Error(
 			"创建用户，生成 Payload 失败",
@@ -115,84 +172,185 @@ func (accountUseCase *AccountUseCase) CreateAccount(c *gin.Context) {
 		return
 	}
 	
-	// 5. 生成用户rsa私钥
-	privateKey, err := generateRsaPrivateKey()
+	// 5. 新建账户请求 (newAccount 请求内嵌 jwk, 不使用 kid)
+	newAccountResp, location, err := accountUseCase.client.NewAccount(c.Request.Context(), directory.NewAccount, payload,
+		step.AccountKey{PrivateKey: privateKey})
+	if err != nil {
+		accountUseCase.logger.Error(
+			"创建用户，新建用户失败",
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	contactByte, err := json.Marshal(newAccountResp.Contact)
 	if err != nil {
 		accountUseCase.logger.Error(
-			"生成RSA PrivateKey失败",
+			"创建用户，序列化Contact失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	buf, err := marshalPKCS1PrivateKey(privateKey)
+	// 6. 更新数据库记录
+	account := Account{
+		Uuid:                 req.UserUuid,
+		Contact:              string(contactByte),
+		TermsOfServiceAgreed: true,
+		PrivateKey:           buf.String(),
+		Status:               newAccountResp.Status,
+		Url:                  location,
+		OrdersUrl:            newAccountResp.Orders,
+		DnsProvider:          req.DnsProvider,
+		CreateTime:           time.Now().Unix(),
+	}
+	
+	err = accountUseCase.accountRepo.CreateAccount(c.Request.Context(), account)
 	if err != nil {
 		accountUseCase.logger.Error(
-			"创建用户，序列化RSA PrivateKey失败",
+			"创建用户，添加用户到数据库失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	// 6. 生成请求Signature
-	newAccountReqContent, err := step.GetSignature(directory.NewAccount, nonce, payload, "", privateKey)
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+	return
+	
+}
+
+// PrivateKey 为 PEM 格式的账户私钥 (PKCS#1、SEC1 或 PKCS#8)
+
+type RecoverAccountReq struct {
+	UserUuid   string `json:"userUuid,omitempty" validate:"required"`
+	PrivateKey string `json:"privateKey" validate:"required"`
+}
+
+// 通过账户私钥找回 CA 中已存在的账户 (onlyReturnExisting)
+
+func (accountUseCase *AccountUseCase) RecoverAccount(c *gin.Context) {
+	req := RecoverAccountReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	// 1. 查看用户是否存在
+	e, err := accountUseCase.accountRepo.ExistAccount(c.Request.Context(), req.UserUuid)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	if e {
+		c.JSON(200, gin.H{"errCode": 0, "errMsg": "该用户已存在"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(req.PrivateKey))
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "无法解析私钥"})
+		return
+	}
+	
+	err = step.CheckAccountKey(privateKey)
+	if err != nil {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "不支持的私钥类型, 需要使用 RSA 或 P-256/P-384 ECDSA 私钥"})
+		return
+	}
+	
+	buf, err := marshalPrivateKey(privateKey)
 	if err != nil {
 		accountUseCase.logger.Error(
-			"创建用户，生成 Signature 失败",
+			"找回用户，序列化PrivateKey失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
-	newAccountReqBody := bytes.NewBuffer([]byte(newAccountReqContent.FullSerialize()))
 	
-	// 7. 新建账户请求
-	newAccountResp, location, _, err := step.NewAccount(directory.NewAccount, newAccountReqBody.Bytes())
+	// 2. 获取 Directory
+	directory, err := accountUseCase.client.Directory(c.Request.Context())
 	if err != nil {
 		accountUseCase.logger.Error(
-			"创建用户，新建用户失败",
+			"找回用户，访问Directory失败",
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	// 3. 生成 Payload, 仅查找已存在的账户, 不创建新账户
+	payload, err := step.GenerateAccountPayload(nil, false, true)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"找回用户，生成 Payload 失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	contactByte, err := json.Marshal(newAccountResp.Contact)
+	// 4. 查找账户请求 (内嵌 jwk)
+	accountResp, location, err := accountUseCase.client.NewAccount(c.Request.Context(), directory.NewAccount, payload,
+		step.AccountKey{PrivateKey: privateKey})
 	if err != nil {
+		if step.IsProblemType(err, step.ErrorAccountDoesNotExist) {
+			c.JSON(404, gin.H{"errCode": 404, "errMsg": "CA 中不存在该私钥对应的账户"})
+			return
+		}
+		
 		accountUseCase.logger.Error(
-			"创建用户，序列化Contact失败",
+			"找回用户，查找用户失败",
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	// 已停用 (deactivated) 或已撤销 (revoked) 的账户无法继续使用
+	if accountResp.Status != "valid" {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": fmt.Sprintf("账户状态为 %s, 无法找回", accountResp.Status)})
+		return
+	}
+	
+	contactByte, err := json.Marshal(accountResp.Contact)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"找回用户，序列化Contact失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	// 8. 更新数据库记录
+	// 5. 更新数据库记录
 	account := Account{
 		Uuid:                 req.UserUuid,
 		Contact:              string(contactByte),
 		TermsOfServiceAgreed: true,
 		PrivateKey:           buf.String(),
-		Status:               newAccountResp.Status,
+		Status:               accountResp.Status,
 		Url:                  location,
+		OrdersUrl:            accountResp.Orders,
 		CreateTime:           time.Now().Unix(),
 	}
 	
 	err = accountUseCase.accountRepo.CreateAccount(c.Request.Context(), account)
 	if err != nil {
 		accountUseCase.logger.Error(
-			"创建用户，添加用户到数据库失败",
+			"找回用户，添加用户到数据库失败",
 			zap.Error(err),
 		)
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
 	
-	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok", "url": location, "status": accountResp.Status})
 	return
-	
 }
 
 // 获取用户
@@ -210,15 +368,327 @@ func (accountUseCase *AccountUseCase) GetAccount(c *gin.Context) {
 	return
 }
 
+type UpdateAccountReq struct {
+	Contact []string `json:"contact" validate:"required"`
+}
+
+// 更新用户联系方式
+
+func (accountUseCase *AccountUseCase) UpdateAccount(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	
+	req := UpdateAccountReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	// 1. 获取用户信息
+	account, err := accountUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，获取用户信息失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，解析用户PrivateKey失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 生成 Payload
+	var contact []string
+	for _, c := range req.Contact {
+		contact = append(contact, fmt.Sprintf("mailto:%s", c))
+	}
+	
+	payload, err := step.GenerateUpdateAccountPayload(contact)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，生成 Payload 失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 3. 在 CA 更新账户
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	accountResp, err := accountUseCase.client.UpdateAccount(c.Request.Context(), payload, accountKey)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，请求CA更新账户失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	contactByte, err := json.Marshal(accountResp.Contact)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，序列化Contact失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 4. 更新数据库记录
+	err = accountUseCase.accountRepo.UpdateAccountContact(c.Request.Context(), userUuid, string(contactByte))
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户，更新数据库Contact失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+	return
+}
+
+// DnsProvider 为空时取消账户指定的 provider
+
+type UpdateAccountDnsProviderReq struct {
+	DnsProvider string `json:"dnsProvider"`
+}
+
+// 更新用户使用的 DNSProvider, 仅保存在数据库, 不请求 CA
+
+func (accountUseCase *AccountUseCase) UpdateAccountDnsProvider(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	
+	req := UpdateAccountDnsProviderReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	if _, ok := accountUseCase.dnsProviders.Get(req.DnsProvider); req.DnsProvider != "" && !ok {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "dnsProvider 不存在"})
+		return
+	}
+	
+	// 1. 查看用户是否存在
+	e, err := accountUseCase.accountRepo.ExistAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	if !e {
+		c.JSON(404, gin.H{"errCode": 404, "errMsg": "该用户不存在"})
+		return
+	}
+	
+	// 2. 更新数据库记录
+	err = accountUseCase.accountRepo.UpdateAccountDnsProvider(c.Request.Context(), userUuid, req.DnsProvider)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更新用户DNSProvider失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+	return
+}
+
 // 删除用户
+// 在 CA 停用账户后仅更新数据库中的状态, 保留记录以便订单仍可对应到账户
 
 func (accountUseCase *AccountUseCase) DelAccount(c *gin.Context) {
 	userUuid := c.Param("uuid")
 	
-	// 调用 acme 协议删除用户
+	// 1. 获取用户信息
+	account, err := accountUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"删除用户，获取用户信息失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	if account.Status == accountStatusDeactivated {
+		c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+		return
+	}
+	
+	privateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		accountUseCase.logger.Error(
+			"删除用户，解析用户PrivateKey失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 调用 acme 协议停用用户
+	accountKey := step.AccountKey{Kid: account.Url, PrivateKey: privateKey}
+	accountResp, err := accountUseCase.client.DeactivationAccount(c.Request.Context(), accountKey)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"删除用户，请求CA停用账户失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	// 3. 更新数据库记录
+	err = accountUseCase.accountRepo.UpdateAccountStatus(c.Request.Context(), userUuid, accountResp.Status)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"删除用户，更新数据库状态失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	c.JSON(200, gin.H{"errCode": 0, "errMsg": "ok"})
+	return
+}
+
+// KeyType 为空时使用 RSA4096
+
+type KeyRolloverReq struct {
+	KeyType string `json:"keyType,omitempty" validate:"omitempty,oneof=RSA2048 RSA3072 RSA4096 EC256 EC384"`
+}
+
+// 更换用户私钥
+
+func (accountUseCase *AccountUseCase) KeyRollover(c *gin.Context) {
+	userUuid := c.Param("uuid")
+	
+	req := KeyRolloverReq{}
+	err := common.JsonUnmarshal(c, &req)
+	if err != nil {
+		return
+	}
+	
+	// 1. 获取用户信息
+	account, err := accountUseCase.accountRepo.GetAccount(c.Request.Context(), userUuid)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，获取用户信息失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	oldPrivateKey, err := parsePrivateKey([]byte(account.PrivateKey))
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，解析用户PrivateKey失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 2. 获取 Directory
+	directory, err := accountUseCase.client.Directory(c.Request.Context())
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，访问Directory失败",
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	if directory.KeyChange == "" {
+		c.JSON(400, gin.H{"errCode": 400, "errMsg": "CA 不支持更换私钥"})
+		return
+	}
+	
+	// 3. 生成新私钥
+	keyType := step.KeyType(req.KeyType)
+	if keyType == "" {
+		keyType = defaultAccountKeyType
+	}
 	
-	err := accountUseCase.accountRepo.DelAccount(c.Request.Context(), userUuid)
+	newPrivateKey, err := step.GeneratePrivateKey(keyType)
 	if err != nil {
+		accountUseCase.logger.Error(
+			"生成PrivateKey失败",
+			zap.String("keyType", string(keyType)),
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	buf, err := marshalPrivateKey(newPrivateKey)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，序列化PrivateKey失败",
+			zap.Error(err),
+		)
+		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
+		return
+	}
+	
+	// 4. 在 CA 更换私钥
+	oldAccountKey := step.AccountKey{Kid: account.Url, PrivateKey: oldPrivateKey}
+	err = accountUseCase.client.KeyChange(c.Request.Context(), directory.KeyChange, oldAccountKey, newPrivateKey)
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，请求keyChange失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		common.ResponseAcmeError(c, err)
+		return
+	}
+	
+	// 5. 更新数据库记录, 失败时将 CA 上的私钥换回旧私钥, 避免数据库中的私钥失效
+	err = accountUseCase.accountRepo.UpdateAccountPrivateKey(c.Request.Context(), userUuid, buf.String())
+	if err != nil {
+		accountUseCase.logger.Error(
+			"更换私钥，更新数据库私钥失败",
+			zap.String("userUuid", userUuid),
+			zap.Error(err),
+		)
+		
+		newAccountKey := step.AccountKey{Kid: account.Url, PrivateKey: newPrivateKey}
+		rollbackErr := accountUseCase.client.KeyChange(c.Request.Context(), directory.KeyChange, newAccountKey, oldPrivateKey)
+		if rollbackErr != nil {
+			accountUseCase.logger.Error(
+				"更换私钥，回滚CA私钥失败",
+				zap.String("userUuid", userUuid),
+				zap.Error(rollbackErr),
+			)
+		}
+		
 		c.JSON(500, gin.H{"errCode": 500, "errMsg": "Internal Server Error"})
 		return
 	}
@@ -250,14 +720,54 @@ func marshalPKCS1PrivateKey(key *rsa.PrivateKey) (bytes.Buffer, error) {
 	return buf, nil
 }
 
-// 解析 rsa 私钥
+// 序列化账户私钥, 使用与类型无关的 PKCS#8 格式
 
-func parsePKCS1PrivateKey(der []byte) (*rsa.PrivateKey, error) {
+func marshalPrivateKey(key crypto.Signer) (bytes.Buffer, error) {
+	var buf bytes.Buffer
+	
+	privateKeyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return buf, err
+	}
+	
+	privateKeyBlock := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: nil,
+		Bytes:   privateKeyDer,
+	}
+	err = pem.Encode(&buf, privateKeyBlock)
+	if err != nil {
+		return buf, err
+	}
+	
+	return buf, nil
+}
+
+// 解析私钥, 兼容 PKCS#8 以及历史数据中的 PKCS#1 (RSA) 和 SEC1 (EC) 格式
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
 	b, _ := pem.Decode(der)
 	
 	if b == nil {
 		return nil, errors.New("block is nil")
 	}
 	
-	return x509.ParsePKCS1PrivateKey(b.Bytes)
+	switch b.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(b.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(b.Bytes)
+	}
+	
+	key, err := x509.ParsePKCS8PrivateKey(b.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+	
+	return signer, nil
 }
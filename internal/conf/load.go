@@ -0,0 +1,29 @@
+package conf
+
+import (
+	"encoding/json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+)
+
+// conf.pb.go 没有 yaml tag, yaml.v3 只匹配小写字段名, 驼峰字段 (如 directoryUrl) 会被忽略
+// 先将 yaml 转换为 json, 再由 protojson 按 proto 字段名解析, 忽略未定义的字段 (如 trace)
+
+func Unmarshal(data []byte, bootstrap *Bootstrap) error {
+	var v interface{}
+	err := yaml.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+	
+	if v == nil {
+		return nil
+	}
+	
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(buf, bootstrap)
+}
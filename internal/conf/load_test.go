@@ -0,0 +1,99 @@
+package conf
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func loadExampleConfig(t *testing.T) *Bootstrap {
+	data, err := os.ReadFile("../../example/config.yaml")
+	require.NoError(t, err)
+	
+	var bootstrap Bootstrap
+	require.NoError(t, Unmarshal(data, &bootstrap))
+	return &bootstrap
+}
+
+func TestUnmarshalExampleConfig(t *testing.T) {
+	bootstrap := loadExampleConfig(t)
+	
+	// 驼峰字段需要正确解析
+	require.Equal(t, "https://acme-v02.api.letsencrypt.org/directory", bootstrap.GetAcme().GetDirectoryUrl())
+	require.Equal(t, "mysql", bootstrap.GetData().GetDatabase().GetDriver())
+	require.Equal(t, int32(10), bootstrap.GetData().GetDatabase().GetMaxIdleConns())
+	require.Equal(t, int32(10), bootstrap.GetData().GetDatabase().GetMaxOpenConns())
+}
+
+func TestUnmarshal(t *testing.T) {
+	var bootstrap Bootstrap
+	require.NoError(t, Unmarshal(nil, &bootstrap))
+	require.Nil(t, bootstrap.GetAcme())
+	
+	// 未定义的字段被忽略, 类型错误时返回错误
+	require.NoError(t, Unmarshal([]byte("unknown: 1\nacme:\n  directoryUrl: https://ca.example.com/directory\n"), &bootstrap))
+	require.Equal(t, "https://ca.example.com/directory", bootstrap.GetAcme().GetDirectoryUrl())
+	
+	require.Error(t, Unmarshal([]byte("data:\n  database:\n    maxIdleConns: many\n"), &bootstrap))
+}
+
+func TestUnmarshalPreferredChain(t *testing.T) {
+	var bootstrap Bootstrap
+	require.NoError(t, Unmarshal([]byte("acme:\n  preferredChain: \"ISRG Root X1\"\n"), &bootstrap))
+	require.Equal(t, "ISRG Root X1", bootstrap.GetAcme().GetPreferredChain())
+	
+	// 示例配置使用 CA 默认证书链
+	require.Equal(t, "", loadExampleConfig(t).GetAcme().GetPreferredChain())
+}
+
+func TestUnmarshalChallenge(t *testing.T) {
+	challenge := loadExampleConfig(t).GetChallenge()
+	require.Equal(t, ":80", challenge.GetHttp01().GetAddr())
+	require.Equal(t, ":443", challenge.GetTlsAlpn01().GetAddr())
+	
+	var bootstrap Bootstrap
+	require.NoError(t, Unmarshal([]byte("challenge:\n  tlsAlpn01:\n    enabled: true\n    addr: \":8443\"\n"), &bootstrap))
+	require.True(t, bootstrap.GetChallenge().GetTlsAlpn01().GetEnabled())
+	require.Equal(t, ":8443", bootstrap.GetChallenge().GetTlsAlpn01().GetAddr())
+}
+
+func TestUnmarshalDnsPropagation(t *testing.T) {
+	dns := loadExampleConfig(t).GetDns()
+	require.Equal(t, []string{"223.5.5.5:53"}, dns.GetDns())
+	require.Equal(t, int32(60), dns.GetPropagationTimeout())
+	require.Equal(t, int32(5), dns.GetPropagationInterval())
+}
+
+func TestUnmarshalRfc2136Provider(t *testing.T) {
+	provider := loadExampleConfig(t).GetDns().GetProviders()[0]
+	require.Equal(t, "rfc2136", provider.GetType())
+	require.Equal(t, []string{"corp.example.com"}, provider.GetDomains())
+	
+	rfc2136 := provider.GetRfc2136()
+	require.Equal(t, "10.0.0.53:53", rfc2136.GetServer())
+	require.Equal(t, "acme-update", rfc2136.GetTsigKey())
+	require.Equal(t, "{base64 secret}", rfc2136.GetTsigSecret())
+	require.Equal(t, "hmac-sha256", rfc2136.GetTsigAlgorithm())
+	require.Equal(t, uint32(120), rfc2136.GetTtl())
+	require.Equal(t, "corp.example.com", rfc2136.GetZones()[0].GetZone())
+}
+
+func TestUnmarshalAlidnsProvider(t *testing.T) {
+	provider := loadExampleConfig(t).GetDns().GetProviders()[1]
+	require.Equal(t, "alidns", provider.GetType())
+	require.Equal(t, int32(120), provider.GetPropagationTimeout())
+	
+	alidns := provider.GetAlidns()
+	require.Equal(t, "{accessKeyId}", alidns.GetAccessKeyId())
+	require.Equal(t, "{accessKeySecret}", alidns.GetAccessKeySecret())
+}
+
+func TestUnmarshalDnspodProvider(t *testing.T) {
+	provider := loadExampleConfig(t).GetDns().GetProviders()[2]
+	require.Equal(t, "dnspod", provider.GetType())
+	
+	dnspod := provider.GetDnspod()
+	require.Equal(t, "{secretId}", dnspod.GetSecretId())
+	require.Equal(t, "{secretKey}", dnspod.GetSecretKey())
+	require.Equal(t, "默认", dnspod.GetRecordLine())
+}
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"context"
+	"github.com/qx66/auto-cert/internal/biz"
+)
+
+type AuthorizationDataSource struct {
+	data *Data
+}
+
+func NewAuthorizationDataSource(data *Data) biz.AuthorizationRepo {
+	return &AuthorizationDataSource{
+		data: data,
+	}
+}
+
+func (authorizationDataSource *AuthorizationDataSource) CreateAuthorization(ctx context.Context, authorization biz.Authorization) error {
+	tx := authorizationDataSource.data.db.WithContext(ctx).Create(&authorization)
+	return tx.Error
+}
+
+func (authorizationDataSource *AuthorizationDataSource) GetAuthorization(ctx context.Context, userUuid, authorizationUuid string) (biz.Authorization, error) {
+	var authorization biz.Authorization
+	tx := authorizationDataSource.data.db.WithContext(ctx).
+		Where("account_uuid = ? and uuid = ?", userUuid, authorizationUuid).
+		First(&authorization)
+	return authorization, tx.Error
+}
+
+func (authorizationDataSource *AuthorizationDataSource) ListAuthorization(ctx context.Context, userUuid string) ([]biz.Authorization, error) {
+	var authorizations []biz.Authorization
+	tx := authorizationDataSource.data.db.WithContext(ctx).
+		Where("account_uuid = ?", userUuid).
+		Find(&authorizations)
+	return authorizations, tx.Error
+}
+
+func (authorizationDataSource *AuthorizationDataSource) UpdateAuthorizationStatus(ctx context.Context, authorizationUuid, status, expires string) error {
+	tx := authorizationDataSource.data.db.WithContext(ctx).
+		Model(&biz.Authorization{}).
+		Where("uuid = ?", authorizationUuid).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"expires": expires,
+		})
+	return tx.Error
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewAccountDataSource, NewOrderDataSource)
+var ProviderSet = wire.NewSet(NewData, NewAccountDataSource, NewOrderDataSource, NewAuthorizationDataSource)
 
 // Data .
 type Data struct {
@@ -89,3 +89,65 @@ func (orderDataSource *OrderDataSource) UpdateOrderStatus(ctx context.Context, o
 		Update("status", status)
 	return tx.Error
 }
+
+func (orderDataSource *OrderDataSource) UpdateOrderNextAttemptAt(ctx context.Context, orderUuid string, nextAttemptAt int64) error {
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Model(&biz.Order{}).
+		Where("uuid = ?", orderUuid).
+		Update("next_attempt_at", nextAttemptAt)
+	return tx.Error
+}
+
+func (orderDataSource *OrderDataSource) UpdateOrderRevoked(ctx context.Context, orderUuid string, revokedAt int64, reason int) error {
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Model(&biz.Order{}).
+		Where("uuid = ?", orderUuid).
+		Updates(map[string]interface{}{
+			"revoked_at":        revokedAt,
+			"revocation_reason": reason,
+		})
+	return tx.Error
+}
+
+func (orderDataSource *OrderDataSource) ListRenewalCheckOrder(ctx context.Context, now int64) ([]biz.Order, error) {
+	var orders []biz.Order
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Where("status = ? and certificate != ? and revoked_at = ? and replaced_by = ? and renewal_check_at <= ?", "valid", "", 0, "", now).
+		Find(&orders)
+	return orders, tx.Error
+}
+
+func (orderDataSource *OrderDataSource) ListRenewalOrder(ctx context.Context, now int64) ([]biz.Order, error) {
+	var orders []biz.Order
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Where("status = ? and revoked_at = ? and replaced_by = ? and renewal_at != ? and renewal_at <= ?", "valid", 0, "", 0, now).
+		Find(&orders)
+	return orders, tx.Error
+}
+
+func (orderDataSource *OrderDataSource) UpdateOrderRenewal(ctx context.Context, orderUuid string, renewalAt, renewalCheckAt int64) error {
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Model(&biz.Order{}).
+		Where("uuid = ?", orderUuid).
+		Updates(map[string]interface{}{
+			"renewal_at":       renewalAt,
+			"renewal_check_at": renewalCheckAt,
+		})
+	return tx.Error
+}
+
+func (orderDataSource *OrderDataSource) UpdateOrderReplacedBy(ctx context.Context, orderUuid, replacedBy string) error {
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Model(&biz.Order{}).
+		Where("uuid = ?", orderUuid).
+		Update("replaced_by", replacedBy)
+	return tx.Error
+}
+
+func (orderDataSource *OrderDataSource) ClearOrderReplacedBy(ctx context.Context, replacedBy string) error {
+	tx := orderDataSource.data.db.WithContext(ctx).
+		Model(&biz.Order{}).
+		Where("replaced_by = ?", replacedBy).
+		Update("replaced_by", "")
+	return tx.Error
+}
@@ -42,9 +42,50 @@ func (accountDataSource *AccountDataSource) ExistAccount(ctx context.Context, uu
 	return true, tx.Error
 }
 
-func (accountDataSource *AccountDataSource) DelAccount(ctx context.Context, uuid string) error {
+func (accountDataSource *AccountDataSource) UpdateAccountNextAttemptAt(ctx context.Context, uuid string, nextAttemptAt int64) error {
 	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
 		Where("uuid = ?", uuid).
-		Delete(&biz.Account{})
+		Update("next_attempt_at", nextAttemptAt)
+	return tx.Error
+}
+
+func (accountDataSource *AccountDataSource) UpdateAccountPrivateKey(ctx context.Context, uuid string, privateKey string) error {
+	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
+		Where("uuid = ?", uuid).
+		Update("private_key", privateKey)
+	return tx.Error
+}
+
+func (accountDataSource *AccountDataSource) UpdateAccountContact(ctx context.Context, uuid string, contact string) error {
+	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
+		Where("uuid = ?", uuid).
+		Update("contact", contact)
+	return tx.Error
+}
+
+func (accountDataSource *AccountDataSource) UpdateAccountDnsProvider(ctx context.Context, uuid string, dnsProvider string) error {
+	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
+		Where("uuid = ?", uuid).
+		Update("dns_provider", dnsProvider)
+	return tx.Error
+}
+
+func (accountDataSource *AccountDataSource) UpdateAccountStatus(ctx context.Context, uuid string, status string) error {
+	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
+		Where("uuid = ?", uuid).
+		Update("status", status)
+	return tx.Error
+}
+
+func (accountDataSource *AccountDataSource) UpdateAccountOrdersUrl(ctx context.Context, uuid string, ordersUrl string) error {
+	tx := accountDataSource.data.db.WithContext(ctx).
+		Model(&biz.Account{}).
+		Where("uuid = ?", uuid).
+		Update("orders_url", ordersUrl)
 	return tx.Error
 }
@@ -55,5 +55,31 @@ func (task *Task) CronJob(ctx context.Context) {
 		)
 	}
 	
+	err = c.AddFunc("1 */3 * * * *", func() {
+		task.orderUseCase.CheckRenewalInfo(ctx)
+	})
+	if err != nil {
+		task.logger.Error(
+			"添加查询证书续期信息任务失败",
+			zap.Error(err),
+		)
+	}
+	
+	err = c.AddFunc("1 */3 * * * *", func() {
+		task.orderUseCase.RenewOrder(ctx)
+	})
+	if err != nil {
+		task.logger.Error(
+			"添加续期订单任务失败",
+			zap.Error(err),
+		)
+	}
+	
 	c.Start()
+	
+	// ctx 取消时停止定时任务
+	go func() {
+		<-ctx.Done()
+		c.Stop()
+	}()
 }
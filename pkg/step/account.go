@@ -1,17 +1,19 @@
 package step
 
 import (
-	"bytes"
+	"context"
+	"crypto"
 	"encoding/json"
 	"errors"
-	"io"
-	"net/http"
+	"gopkg.in/square/go-jose.v2"
 )
 
 type AcctRequestPayload struct {
-	Contact              []string `json:"contact"`
+	Contact              []string `json:"contact,omitempty"`
 	TermsOfServiceAgreed bool     `json:"termsOfServiceAgreed"`
 	OnlyReturnExisting   bool     `json:"onlyReturnExisting,omitempty"` // 仅用于查找，不希望创建时，设置为 true
+	
+	ExternalAccountBinding json.RawMessage `json:"externalAccountBinding,omitempty"` // CA 要求 EAB 时必须携带
 }
 
 func (acctRequestPayload AcctRequestPayload) String() string {
@@ -34,11 +36,19 @@ type NewAccountResponse struct {
 
 type ResponseKey struct {
 	Kty string `json:"kty,omitempty"`
-	N   string `json:"n,omitempty"`
-	E   string `json:"e,omitempty"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // EC
+	X   string `json:"x,omitempty"`   // EC
+	Y   string `json:"y,omitempty"`   // EC
 }
 
+// 即请求中携带的 External Account Binding JWS
+
 type NewAccountResponseExternalAccountBinding struct {
+	Protected string `json:"protected,omitempty"`
+	Payload   string `json:"payload,omitempty"`
+	Signature string `json:"signature,omitempty"`
 }
 
 func (newAccountResponse NewAccountResponse) String() string {
@@ -46,50 +56,88 @@ func (newAccountResponse NewAccountResponse) String() string {
 	return string(b)
 }
 
-// req => json.Marshal(struct)
+// payload => GenerateAccountPayload
 // return => NewAccountResponse, Location, error
 // https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.1
 
-func NewAccount(url string, req []byte) (NewAccountResponse, string, string, error) {
+func (client *Client) NewAccount(ctx context.Context, url, payload string, key AccountKey) (NewAccountResponse, string, error) {
 	var newAccountResponse NewAccountResponse
 	
-	param := bytes.NewBuffer(req)
-	
-	//fmt.Println("param: ", param.String())
-	resp, err := http.Post(url, "application/jose+json", param)
+	resp, respBodyByte, err := client.postJWS(ctx, url, payload, key)
 	if err != nil {
-		return newAccountResponse, "", "", err
+		return newAccountResponse, "", err
 	}
 	
-	respBody := resp.Body
-	defer resp.Body.Close()
+	location := resp.Header.Get("Location")
 	
-	respBodyByte, err := io.ReadAll(respBody)
+	err = json.Unmarshal(respBodyByte, &newAccountResponse)
 	if err != nil {
-		return newAccountResponse, "", "", err
+		return newAccountResponse, "", err
 	}
 	
-	if resp.StatusCode == 400 {
-		return newAccountResponse, "", "", errors.New(string(respBodyByte))
+	return newAccountResponse, location, nil
+}
+
+type keyChangePayload struct {
+	Account string          `json:"account"` // 账户 url
+	OldKey  jose.JSONWebKey `json:"oldKey"`  // 旧公钥 jwk
+}
+
+// 更换账户私钥, 外层 JWS 使用旧私钥 (kid) 签名, 内层 JWS 使用新私钥 (jwk) 签名
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.5
+
+func (client *Client) KeyChange(ctx context.Context, url string, key AccountKey, newPrivateKey crypto.Signer) error {
+	if key.Kid == "" {
+		return errors.New("account url is empty")
 	}
 	
-	location := resp.Header.Get("Location")
-	replayNonce := resp.Header.Get("Replay-Nonce")
+	payload := keyChangePayload{
+		Account: key.Kid,
+		OldKey:  jose.JSONWebKey{Key: key.PrivateKey.Public()},
+	}
 	
-	err = json.Unmarshal(respBodyByte, &newAccountResponse)
+	payloadByte, err := json.Marshal(payload)
 	if err != nil {
-		return newAccountResponse, "", "", err
+		return err
 	}
 	
-	return newAccountResponse, location, replayNonce, nil
+	inner, err := GetKeyChangeSignature(url, string(payloadByte), newPrivateKey)
+	if err != nil {
+		return err
+	}
+	
+	_, _, err = client.postJWS(ctx, url, inner.FullSerialize(), key)
+	return err
 }
 
-func UpdateAccount(accountUrl string) {
+// 更新账户信息 (contact), 账户 url 为 key.Kid
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.2
+
+func (client *Client) UpdateAccount(ctx context.Context, payload string, key AccountKey) (NewAccountResponse, error) {
+	var newAccountResponse NewAccountResponse
+	
+	if key.Kid == "" {
+		return newAccountResponse, errors.New("account url is empty")
+	}
+	
+	_, respBodyByte, err := client.postJWS(ctx, key.Kid, payload, key)
+	if err != nil {
+		return newAccountResponse, err
+	}
 	
+	err = json.Unmarshal(respBodyByte, &newAccountResponse)
+	if err != nil {
+		return newAccountResponse, err
+	}
+	
+	return newAccountResponse, nil
 }
 
-func DeactivationAccount(accountUrl string) {
-	
+// 停用账户, 停用后 CA 将拒绝该账户的所有请求, 且无法重新激活
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.6
+
+func (client *Client) DeactivationAccount(ctx context.Context, key AccountKey) (NewAccountResponse, error) {
+	return client.UpdateAccount(ctx, `{"status":"deactivated"}`, key)
 }
 
 // 生成 payload
@@ -108,3 +156,42 @@ func GenerateAccountPayload(mailTo []string, termsOfServiceAgreed, onlyReturnExi
 	
 	return string(payloadByte), nil
 }
+
+type updateAccountPayload struct {
+	Contact []string `json:"contact"`
+}
+
+func GenerateUpdateAccountPayload(mailTo []string) (string, error) {
+	payload := updateAccountPayload{
+		Contact: mailTo,
+	}
+	
+	payloadByte, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	
+	return string(payloadByte), nil
+}
+
+// 生成携带 External Account Binding 的 payload, url 为 newAccount 地址
+
+func GenerateExternalAccountPayload(url string, mailTo []string, termsOfServiceAgreed bool, eabKid, eabHmacKey string, accountKey crypto.Signer) (string, error) {
+	eab, err := GetExternalAccountBinding(url, eabKid, eabHmacKey, accountKey)
+	if err != nil {
+		return "", err
+	}
+	
+	payload := AcctRequestPayload{
+		Contact:                mailTo,
+		TermsOfServiceAgreed:   termsOfServiceAgreed,
+		ExternalAccountBinding: json.RawMessage(eab.FullSerialize()),
+	}
+	
+	payloadByte, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	
+	return string(payloadByte), nil
+}
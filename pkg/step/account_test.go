@@ -1 +1,195 @@
 package step
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestKeyChange(t *testing.T) {
+	oldKey, err := GeneratePrivateKey(KeyTypeRSA2048)
+	require.NoError(t, err)
+	
+	newKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	accountUrl := server.URL + "/acct/1"
+	keyChangeUrl := server.URL + "/key-change"
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/key-change", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		outer, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, accountUrl, outer.Signatures[0].Header.KeyID)
+		
+		innerByte, err := outer.Verify(oldKey.Public())
+		assert.NoError(t, err, "外层 JWS 需要使用旧私钥签名")
+		
+		inner, err := jose.ParseSigned(string(innerByte))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Empty(t, inner.Signatures[0].Header.Nonce, "内层 JWS 不能包含 nonce")
+		assert.Equal(t, keyChangeUrl, inner.Signatures[0].Protected.ExtraHeaders["url"])
+		
+		payloadByte, err := inner.Verify(newKey.Public())
+		assert.NoError(t, err, "内层 JWS 需要使用新私钥签名")
+		
+		var payload keyChangePayload
+		if !assert.NoError(t, json.Unmarshal(payloadByte, &payload)) {
+			return
+		}
+		assert.Equal(t, accountUrl, payload.Account)
+		
+		oldJwk := jose.JSONWebKey{Key: oldKey.Public()}
+		thumbprint, _ := oldJwk.Thumbprint(crypto.SHA256)
+		oldThumbprint, _ := payload.OldKey.Thumbprint(crypto.SHA256)
+		assert.Equal(t, thumbprint, oldThumbprint)
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	err = client.KeyChange(context.Background(), keyChangeUrl, AccountKey{Kid: accountUrl, PrivateKey: oldKey}, newKey)
+	require.NoError(t, err)
+	
+	err = client.KeyChange(context.Background(), keyChangeUrl, AccountKey{PrivateKey: oldKey}, newKey)
+	require.Error(t, err, "未设置账户 url 时不能更换私钥")
+}
+
+func TestUpdateAccount(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	accountUrl := server.URL + "/acct/1"
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/acct/1", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, accountUrl, jws.Signatures[0].Header.KeyID)
+		
+		payloadByte, err := jws.Verify(privateKey.Public())
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		var payload map[string]interface{}
+		assert.NoError(t, json.Unmarshal(payloadByte, &payload))
+		
+		status := "valid"
+		if payload["status"] == "deactivated" {
+			status = "deactivated"
+		}
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		json.NewEncoder(w).Encode(NewAccountResponse{Status: status, Contact: []string{"mailto:admin@example.com"}})
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	key := AccountKey{Kid: accountUrl, PrivateKey: privateKey}
+	
+	payload, err := GenerateUpdateAccountPayload([]string{"mailto:admin@example.com"})
+	require.NoError(t, err)
+	
+	account, err := client.UpdateAccount(context.Background(), payload, key)
+	require.NoError(t, err)
+	require.Equal(t, "valid", account.Status)
+	require.Equal(t, []string{"mailto:admin@example.com"}, account.Contact)
+	
+	account, err = client.DeactivationAccount(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, "deactivated", account.Status)
+}
+
+func TestGenerateExternalAccountPayload(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	hmacKey := []byte("0123456789abcdef0123456789abcdef")
+	url := "https://example.com/acme/new-account"
+	
+	payload, err := GenerateExternalAccountPayload(url, []string{"mailto:admin@example.com"}, true, "kid-1",
+		base64.RawURLEncoding.EncodeToString(hmacKey), privateKey)
+	require.NoError(t, err)
+	
+	var acctRequestPayload AcctRequestPayload
+	require.NoError(t, json.Unmarshal([]byte(payload), &acctRequestPayload))
+	require.NotEmpty(t, acctRequestPayload.ExternalAccountBinding)
+	
+	eab, err := jose.ParseSigned(string(acctRequestPayload.ExternalAccountBinding))
+	require.NoError(t, err)
+	require.Equal(t, string(jose.HS256), eab.Signatures[0].Header.Algorithm)
+	require.Equal(t, "kid-1", eab.Signatures[0].Header.KeyID)
+	require.Equal(t, url, eab.Signatures[0].Protected.ExtraHeaders["url"])
+	require.Empty(t, eab.Signatures[0].Header.Nonce, "EAB JWS 不能包含 nonce")
+	
+	jwkByte, err := eab.Verify(hmacKey)
+	require.NoError(t, err, "EAB 需要使用 hmac key 签名")
+	
+	var jwk jose.JSONWebKey
+	require.NoError(t, json.Unmarshal(jwkByte, &jwk))
+	require.True(t, jwk.IsPublic())
+	
+	_, err = GenerateExternalAccountPayload(url, nil, true, "kid-1", "not base64!", privateKey)
+	require.Error(t, err)
+}
+
+func TestNewAccountOnlyReturnExisting(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeRSA2048)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotNil(t, jws.Signatures[0].Header.JSONWebKey, "查找账户需要内嵌 jwk")
+		
+		payloadByte, err := jws.Verify(privateKey.Public())
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		var payload AcctRequestPayload
+		assert.NoError(t, json.Unmarshal(payloadByte, &payload))
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		if !payload.OnlyReturnExisting {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		
+		w.Header().Set("Location", server.URL+"/acct/1")
+		json.NewEncoder(w).Encode(NewAccountResponse{Status: "valid", Contact: []string{"mailto:admin@example.com"}})
+	})
+	
+	payload, err := GenerateAccountPayload(nil, false, true)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"termsOfServiceAgreed":false,"onlyReturnExisting":true}`, payload)
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	account, location, err := client.NewAccount(context.Background(), server.URL+"/account", payload, AccountKey{PrivateKey: privateKey})
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/acct/1", location)
+	require.Equal(t, "valid", account.Status)
+}
@@ -0,0 +1,279 @@
+package step
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 阿里云云解析 DNS (Alidns), 使用 AccessKey 按 RPC 签名机制签名请求
+// https://help.aliyun.com/document_detail/29747.html
+
+const (
+	defaultAlidnsEndpoint = "https://alidns.aliyuncs.com/"
+	defaultAlidnsTTL      = 600
+	alidnsApiVersion      = "2015-01-09"
+	alidnsPageSize        = 100
+)
+
+type AlidnsConfig struct {
+	AccessKeyId         string
+	AccessKeySecret     string
+	Endpoint            string // 为空时使用 defaultAlidnsEndpoint
+	TTL                 int    // 为 0 时使用 defaultAlidnsTTL
+	HttpClient          *http.Client
+	PropagationTimeout  time.Duration
+	PropagationInterval time.Duration
+}
+
+type AlidnsProvider struct {
+	config AlidnsConfig
+}
+
+func NewAlidnsProvider(config AlidnsConfig) (*AlidnsProvider, error) {
+	if config.AccessKeyId == "" || config.AccessKeySecret == "" {
+		return nil, fmt.Errorf("alidns: accessKeyId and accessKeySecret are required")
+	}
+	
+	if config.Endpoint == "" {
+		config.Endpoint = defaultAlidnsEndpoint
+	}
+	
+	if config.TTL == 0 {
+		config.TTL = defaultAlidnsTTL
+	}
+	
+	if config.HttpClient == nil {
+		config.HttpClient = &http.Client{Timeout: defaultHttpTimeout}
+	}
+	
+	return &AlidnsProvider{config: config}, nil
+}
+
+type alidnsError struct {
+	RequestId string `json:"RequestId"`
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+}
+
+func (e *alidnsError) Error() string {
+	return fmt.Sprintf("alidns: %s: %s (RequestId: %s)", e.Code, e.Message, e.RequestId)
+}
+
+type alidnsRecord struct {
+	RecordId string `json:"RecordId"`
+	RR       string `json:"RR"`
+	Type     string `json:"Type"`
+	Value    string `json:"Value"`
+}
+
+// 记录已存在时 AddDomainRecord 返回 DomainRecordDuplicate, 视为添加成功
+
+func (provider *AlidnsProvider) Present(ctx context.Context, fqdn, value string) error {
+	zone, rr, err := provider.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	
+	err = provider.request(ctx, "AddDomainRecord", map[string]string{
+		"DomainName": zone,
+		"RR":         rr,
+		"Type":       "TXT",
+		"Value":      value,
+		"TTL":        strconv.Itoa(provider.config.TTL),
+	}, nil)
+	if e, ok := err.(*alidnsError); ok && e.Code == "DomainRecordDuplicate" {
+		return nil
+	}
+	
+	return err
+}
+
+// 只删除 RR 和值均匹配的记录
+
+func (provider *AlidnsProvider) CleanUp(ctx context.Context, fqdn, value string) error {
+	zone, rr, err := provider.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	
+	var resp struct {
+		DomainRecords struct {
+			Record []alidnsRecord `json:"Record"`
+		} `json:"DomainRecords"`
+	}
+	err = provider.request(ctx, "DescribeDomainRecords", map[string]string{
+		"DomainName":  zone,
+		"RRKeyWord":   rr,
+		"TypeKeyWord": "TXT",
+		"PageSize":    strconv.Itoa(alidnsPageSize),
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	
+	for _, record := range resp.DomainRecords.Record {
+		if record.RR != rr || record.Type != "TXT" || record.Value != value {
+			continue
+		}
+		
+		err = provider.request(ctx, "DeleteDomainRecord", map[string]string{"RecordId": record.RecordId}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+func (provider *AlidnsProvider) Timeout() (time.Duration, time.Duration) {
+	return provider.config.PropagationTimeout, provider.config.PropagationInterval
+}
+
+// 在账户已添加的域名中按后缀最长匹配, 返回域名及主机记录 (RR)
+
+func (provider *AlidnsProvider) findZone(ctx context.Context, fqdn string) (string, string, error) {
+	name := strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	
+	zone := ""
+	for page := 1; ; page++ {
+		var resp struct {
+			TotalCount int `json:"TotalCount"`
+			Domains    struct {
+				Domain []struct {
+					DomainName string `json:"DomainName"`
+				} `json:"Domain"`
+			} `json:"Domains"`
+		}
+		err := provider.request(ctx, "DescribeDomains", map[string]string{
+			"PageNumber": strconv.Itoa(page),
+			"PageSize":   strconv.Itoa(alidnsPageSize),
+		}, &resp)
+		if err != nil {
+			return "", "", err
+		}
+		
+		for _, domain := range resp.Domains.Domain {
+			domainName := strings.ToLower(domain.DomainName)
+			if name != domainName && !strings.HasSuffix(name, "."+domainName) {
+				continue
+			}
+			
+			if len(domainName) > len(zone) {
+				zone = domainName
+			}
+		}
+		
+		if page*alidnsPageSize >= resp.TotalCount {
+			break
+		}
+	}
+	
+	if zone == "" {
+		return "", "", fmt.Errorf("alidns: could not find domain for %s", fqdn)
+	}
+	
+	rr := strings.TrimSuffix(strings.TrimSuffix(name, zone), ".")
+	if rr == "" {
+		rr = "@"
+	}
+	
+	return zone, rr, nil
+}
+
+func (provider *AlidnsProvider) request(ctx context.Context, action string, params map[string]string, v interface{}) error {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+	
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	query.Set("Action", action)
+	query.Set("Format", "JSON")
+	query.Set("Version", alidnsApiVersion)
+	query.Set("AccessKeyId", provider.config.AccessKeyId)
+	query.Set("SignatureMethod", "HMAC-SHA1")
+	query.Set("SignatureVersion", "1.0")
+	query.Set("SignatureNonce", hex.EncodeToString(nonce))
+	query.Set("Timestamp", time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("Signature", AlidnsSignature(http.MethodGet, query, provider.config.AccessKeySecret))
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.config.Endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", defaultUserAgent)
+	
+	resp, err := provider.config.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	
+	if resp.StatusCode != http.StatusOK {
+		e := &alidnsError{}
+		if json.Unmarshal(body, e) != nil || e.Code == "" {
+			return fmt.Errorf("alidns: %s: unexpected status %d", action, resp.StatusCode)
+		}
+		return e
+	}
+	
+	if v == nil {
+		return nil
+	}
+	
+	return json.Unmarshal(body, v)
+}
+
+// RPC 签名: 参数按名称排序后编码为 StringToSign, 使用 AccessKeySecret + "&" 计算 HMAC-SHA1
+
+func AlidnsSignature(method string, query url.Values, accessKeySecret string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key != "Signature" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, alidnsPercentEncode(key)+"="+alidnsPercentEncode(query.Get(key)))
+	}
+	
+	stringToSign := method + "&" + alidnsPercentEncode("/") + "&" + alidnsPercentEncode(strings.Join(pairs, "&"))
+	
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// RFC 3986 编码, 空格编码为 %20, * 编码为 %2A, ~ 不编码
+
+func alidnsPercentEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
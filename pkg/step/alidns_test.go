@@ -0,0 +1,136 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// 官方文档中的签名示例
+// https://help.aliyun.com/document_detail/29747.html
+
+func TestAlidnsSignature(t *testing.T) {
+	query := url.Values{}
+	query.Set("AccessKeyId", "testid")
+	query.Set("Action", "DescribeDomainRecords")
+	query.Set("DomainName", "example.com")
+	query.Set("Format", "XML")
+	query.Set("SignatureMethod", "HMAC-SHA1")
+	query.Set("SignatureNonce", "f59ed6a9-83fc-473b-9cc6-99c95df3856e")
+	query.Set("SignatureVersion", "1.0")
+	query.Set("Timestamp", "2016-03-24T16:41:54Z")
+	query.Set("Version", "2015-01-09")
+	
+	require.Equal(t, "uRpHwaSEt3J+6KQD//svCh/x+pI=", AlidnsSignature("GET", query, "testsecret"))
+}
+
+// 模拟 Alidns API, 校验签名后按 Action 处理请求
+
+type testAlidnsServer struct {
+	mu      sync.Mutex
+	nextId  int
+	records map[string]alidnsRecord // RecordId -> 记录
+	domains map[string]string       // RecordId -> DomainName
+}
+
+func (server *testAlidnsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("AccessKeyId") != "test-id" || query.Get("Signature") != AlidnsSignature(r.Method, query, "test-secret") {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(alidnsError{RequestId: "1", Code: "SignatureDoesNotMatch", Message: "signature mismatch"})
+		return
+	}
+	
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	
+	switch query.Get("Action") {
+	case "DescribeDomains":
+		// 每页 100 条, 第二页返回 corp.example.com
+		domain := "example.com"
+		if query.Get("PageNumber") == "2" {
+			domain = "corp.example.com"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"TotalCount": 101,
+			"Domains":    map[string]interface{}{"Domain": []map[string]string{{"DomainName": domain}}},
+		})
+	case "AddDomainRecord":
+		for _, record := range server.records {
+			if record.RR == query.Get("RR") && record.Value == query.Get("Value") {
+				w.WriteHeader(400)
+				json.NewEncoder(w).Encode(alidnsError{RequestId: "1", Code: "DomainRecordDuplicate", Message: "The DNS record already exists."})
+				return
+			}
+		}
+		
+		server.nextId++
+		recordId := strconv.Itoa(server.nextId)
+		server.records[recordId] = alidnsRecord{RecordId: recordId, RR: query.Get("RR"), Type: query.Get("Type"), Value: query.Get("Value")}
+		server.domains[recordId] = query.Get("DomainName")
+		json.NewEncoder(w).Encode(map[string]string{"RequestId": "1", "RecordId": recordId})
+	case "DescribeDomainRecords":
+		// RRKeyWord 为模糊匹配
+		records := []alidnsRecord{}
+		for recordId, record := range server.records {
+			if server.domains[recordId] == query.Get("DomainName") {
+				records = append(records, record)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"TotalCount":    len(records),
+			"DomainRecords": map[string]interface{}{"Record": records},
+		})
+	case "DeleteDomainRecord":
+		delete(server.records, query.Get("RecordId"))
+		json.NewEncoder(w).Encode(map[string]string{"RequestId": "1", "RecordId": query.Get("RecordId")})
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func TestAlidnsProvider(t *testing.T) {
+	handler := &testAlidnsServer{records: make(map[string]alidnsRecord), domains: make(map[string]string)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	
+	provider, err := NewAlidnsProvider(AlidnsConfig{AccessKeyId: "test-id", AccessKeySecret: "test-secret", Endpoint: server.URL + "/"})
+	require.NoError(t, err)
+	
+	// 子域名使用最长匹配的域名
+	ctx := context.Background()
+	zone, rr, err := provider.findZone(ctx, "_acme-challenge.www.corp.example.com.")
+	require.NoError(t, err)
+	require.Equal(t, "corp.example.com", zone)
+	require.Equal(t, "_acme-challenge.www", rr)
+	
+	_, _, err = provider.findZone(ctx, "_acme-challenge.example.org.")
+	require.Error(t, err)
+	
+	// 同一 FQDN 添加两个值, 重复添加视为成功
+	fqdn := "_acme-challenge.example.com."
+	require.NoError(t, provider.Present(ctx, fqdn, "value-1"))
+	require.NoError(t, provider.Present(ctx, fqdn, "value-2"))
+	require.NoError(t, provider.Present(ctx, fqdn, "value-1"))
+	require.Len(t, handler.records, 2)
+	require.Equal(t, "example.com", handler.domains["1"])
+	require.Equal(t, alidnsRecord{RecordId: "1", RR: "_acme-challenge", Type: "TXT", Value: "value-1"}, handler.records["1"])
+	
+	// 删除时只删除对应的值
+	require.NoError(t, provider.CleanUp(ctx, fqdn, "value-1"))
+	require.Len(t, handler.records, 1)
+	require.Equal(t, "value-2", handler.records["2"].Value)
+	
+	// 签名错误
+	provider, err = NewAlidnsProvider(AlidnsConfig{AccessKeyId: "test-id", AccessKeySecret: "wrong-secret", Endpoint: server.URL + "/"})
+	require.NoError(t, err)
+	err = provider.Present(ctx, fqdn, "value-3")
+	require.Error(t, err)
+	require.Equal(t, "SignatureDoesNotMatch", err.(*alidnsError).Code)
+}
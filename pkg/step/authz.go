@@ -1,10 +1,10 @@
 package step
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
-	"io"
-	"net/http"
+	"errors"
+	"strings"
 )
 
 // 5 认证
@@ -18,45 +18,71 @@ type Authorization struct {
 }
 
 type Challenge struct {
-	Type      string    `json:"type"`
-	Status    string    `json:"status"`
-	Url       string    `json:"url"`
-	Token     string    `json:"token"`
-	Validated string    `json:"validated,omitempty"`
-	Error     ACMEError `json:"error,omitempty"`
+	Type      string   `json:"type"`
+	Status    string   `json:"status"`
+	Url       string   `json:"url"`
+	Token     string   `json:"token"`
+	Validated string   `json:"validated,omitempty"`
+	Error     *Problem `json:"error,omitempty"` // 验证失败时的错误信息
 }
 
-type ACMEError struct {
-	Type   string `json:"type,omitempty"`
-	Detail string `json:"detail,omitempty"`
+func (client *Client) GetOrderAuthorization(ctx context.Context, orderAuthorizationUrl string, key AccountKey) (Authorization, error) {
+	var authorization Authorization
+	_, respBodyByte, err := client.postJWS(ctx, orderAuthorizationUrl, "", key)
+	if err != nil {
+		return authorization, err
+	}
+	
+	err = json.Unmarshal(respBodyByte, &authorization)
+	return authorization, err
+}
+
+type NewAuthzPayload struct {
+	Identifier Identifier `json:"identifier"`
 }
 
-func GetOrderAuthorization(orderAuthorizationUrl string, req []byte) (Authorization, string, error) {
+// 预授权, CA 未实现 newAuthz 时 directory 中不包含该字段
+// 预授权不能使用通配符 identifier
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.4.1
+
+func (client *Client) NewAuthorization(ctx context.Context, newAuthzUrl, payload string, key AccountKey) (Authorization, string, error) {
 	var authorization Authorization
-	param := bytes.NewBuffer(req)
-	resp, err := http.Post(orderAuthorizationUrl, "application/jose+json", param)
+	resp, respBodyByte, err := client.postJWS(ctx, newAuthzUrl, payload, key)
 	if err != nil {
 		return authorization, "", err
 	}
 	
-	respBody := resp.Body
-	defer resp.Body.Close()
+	location := resp.Header.Get("Location")
 	
-	respBodyByte, err := io.ReadAll(respBody)
-	if err != nil {
-		return authorization, "", err
+	err = json.Unmarshal(respBodyByte, &authorization)
+	return authorization, location, err
+}
+
+func GenerateNewAuthzPayload(identifier Identifier) (string, error) {
+	if strings.HasPrefix(identifier.Value, "*.") {
+		return "", errors.New("newAuthz not support wildcard identifier")
 	}
 	
-	err = json.Unmarshal(respBodyByte, &authorization)
+	payloadByte, err := json.Marshal(NewAuthzPayload{Identifier: identifier})
 	if err != nil {
-		return authorization, "", nil
+		return "", err
 	}
-	replayNonce := resp.Header.Get("Replay-Nonce")
-	return authorization, replayNonce, nil
+	
+	return string(payloadByte), nil
 }
 
-func DeactivatingAuthorization(orderAuthorizationUrl string, req []byte) {
+// 停用 authorization, 只有 pending 和 valid 状态可以停用
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.5.2
 
+func (client *Client) DeactivatingAuthorization(ctx context.Context, orderAuthorizationUrl string, key AccountKey) (Authorization, error) {
+	var authorization Authorization
+	_, respBodyByte, err := client.postJWS(ctx, orderAuthorizationUrl, `{"status":"deactivated"}`, key)
+	if err != nil {
+		return authorization, err
+	}
+	
+	err = json.Unmarshal(respBodyByte, &authorization)
+	return authorization, err
 }
 
 // DNS Challenge
@@ -68,28 +94,15 @@ func DeactivatingAuthorization(orderAuthorizationUrl string, req []byte) {
 //   this challenge once the challenge is complete, i.e., once the
 //   "status" field of the challenge has the value "valid" or "invalid".
 
-func GetOrderAuthorizationChallenge(orderAuthorizationChallengeUrl string, req []byte) (Challenge, string, error) {
+// 响应 challenge, payload 为 {}, 通知服务端开始验证
+
+func (client *Client) GetOrderAuthorizationChallenge(ctx context.Context, orderAuthorizationChallengeUrl string, key AccountKey) (Challenge, error) {
 	var challenge Challenge
-	param := bytes.NewBuffer(req)
-	resp, err := http.Post(orderAuthorizationChallengeUrl, "application/jose+json", param)
-	if err != nil {
-		return challenge, "", err
-	}
-	
-	respBody := resp.Body
-	defer resp.Body.Close()
-	
-	respBodyByte, err := io.ReadAll(respBody)
+	_, respBodyByte, err := client.postJWS(ctx, orderAuthorizationChallengeUrl, "{}", key)
 	if err != nil {
-		return challenge, "", err
+		return challenge, err
 	}
 	
 	err = json.Unmarshal(respBodyByte, &challenge)
-	if err != nil {
-		return challenge, "", nil
-	}
-	
-	replayNonce := resp.Header.Get("Replay-Nonce")
-	
-	return challenge, replayNonce, err
+	return challenge, err
 }
@@ -0,0 +1,94 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDeactivatingAuthorization(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/authz/1", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		payloadByte, err := jws.Verify(privateKey.Public())
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.JSONEq(t, `{"status":"deactivated"}`, string(payloadByte))
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		json.NewEncoder(w).Encode(Authorization{
+			Identifier: Identifier{Type: "dns", Value: "www.example.com"},
+			Status:     "deactivated",
+		})
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	authorization, err := client.DeactivatingAuthorization(context.Background(), server.URL+"/authz/1",
+		AccountKey{Kid: server.URL + "/acct/1", PrivateKey: privateKey})
+	require.NoError(t, err)
+	require.Equal(t, "deactivated", authorization.Status)
+	require.Equal(t, "www.example.com", authorization.Identifier.Value)
+}
+
+func TestNewAuthorization(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/new-authz", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		payloadByte, err := jws.Verify(privateKey.Public())
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		var payload NewAuthzPayload
+		assert.NoError(t, json.Unmarshal(payloadByte, &payload))
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		w.Header().Set("Location", server.URL+"/authz/2")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Authorization{
+			Identifier: payload.Identifier,
+			Status:     "pending",
+			Challenges: []Challenge{{Type: "dns-01", Status: "pending", Url: server.URL + "/chall/1", Token: "token"}},
+		})
+	})
+	
+	payload, err := GenerateNewAuthzPayload(Identifier{Type: "dns", Value: "www.example.com"})
+	require.NoError(t, err)
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	authorization, location, err := client.NewAuthorization(context.Background(), server.URL+"/new-authz", payload,
+		AccountKey{Kid: server.URL + "/acct/1", PrivateKey: privateKey})
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/authz/2", location)
+	require.Equal(t, "www.example.com", authorization.Identifier.Value)
+	require.Len(t, authorization.Challenges, 1)
+	
+	_, err = GenerateNewAuthzPayload(Identifier{Type: "dns", Value: "*.example.com"})
+	require.Error(t, err, "预授权不能使用通配符")
+}
@@ -1,35 +1,143 @@
 package step
 
 import (
-	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
-	"io"
-	"net/http"
+	"fmt"
 )
 
-func DownloadCertificate(certificateUrl string, req []byte) (string, error) {
-	param := bytes.NewBuffer(req)
-	
-	resp, err := http.Post(certificateUrl, "application/jose+json", param)
+func (client *Client) DownloadCertificate(ctx context.Context, certificateUrl string, key AccountKey) (string, error) {
+	_, respBodyByte, err := client.postJWS(ctx, certificateUrl, "", key)
 	if err != nil {
 		return "", err
 	}
 	
-	respBody := resp.Body
-	defer resp.Body.Close()
-	
-	respBodyByte, err := io.ReadAll(respBody)
+	return string(respBodyByte), nil
+}
+
+// 下载默认证书链以及响应头 Link rel="alternate" 中的备用证书链, 第一个为默认证书链
+// 备用证书链下载失败时跳过, 返回已下载的证书链及备用证书链的错误
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.4.2
+
+func (client *Client) DownloadCertificates(ctx context.Context, certificateUrl string, key AccountKey) ([]string, error) {
+	resp, respBodyByte, err := client.postJWS(ctx, certificateUrl, "", key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return "", errors.New(string(respBodyByte))
+	chains := []string{string(respBodyByte)}
+	
+	var errs []error
+	for _, alternateUrl := range getLinks(resp.Header, "alternate") {
+		chain, err := client.DownloadCertificate(ctx, alternateUrl, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("download alternate chain %s: %w", alternateUrl, err))
+			continue
+		}
+		
+		chains = append(chains, chain)
 	}
 	
-	return string(respBodyByte), nil
+	return chains, errors.Join(errs...)
+}
+
+// 按证书链最顶层证书的签发者 CN 选择证书链, 没有匹配时返回默认证书链
+
+func SelectPreferredChain(chains []string, preferredChain string) string {
+	if len(chains) == 0 {
+		return ""
+	}
+	
+	if preferredChain == "" {
+		return chains[0]
+	}
+	
+	for _, chain := range chains {
+		var top *x509.Certificate
+		rest := []byte(chain)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				break
+			}
+			top = cert
+		}
+		
+		if top != nil && top.Issuer.CommonName == preferredChain {
+			return chain
+		}
+	}
+	
+	return chains[0]
 }
 
-func RevokeCertificate() {
+// 解析 PEM 格式证书链中的第一个证书
 
+func ParseLeafCertificate(certificate string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode certificate pem")
+	}
+	
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// 撤销原因, RFC 5280 CRLReason (7 未使用)
+// https://datatracker.ietf.org/doc/html/rfc5280#section-5.3.1
+
+const (
+	RevocationReasonUnspecified          = 0
+	RevocationReasonKeyCompromise        = 1
+	RevocationReasonCACompromise         = 2
+	RevocationReasonAffiliationChanged   = 3
+	RevocationReasonSuperseded           = 4
+	RevocationReasonCessationOfOperation = 5
+	RevocationReasonCertificateHold      = 6
+	RevocationReasonRemoveFromCRL        = 8
+	RevocationReasonPrivilegeWithdrawn   = 9
+	RevocationReasonAACompromise         = 10
+)
+
+type RevokeCertificatePayload struct {
+	Certificate string `json:"certificate"` // base64url(DER)
+	Reason      int    `json:"reason"`
+}
+
+// 撤销证书, 使用账户私钥 (kid) 或证书私钥 (jwk) 签名
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.6
+
+func (client *Client) RevokeCertificate(ctx context.Context, url, payload string, key AccountKey) error {
+	_, _, err := client.postJWS(ctx, url, payload, key)
+	return err
+}
+
+// certificate 为 PEM 格式证书链, 只撤销第一个证书
+
+func GenerateRevokeCertificatePayload(certificate string, reason int) (string, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("failed to decode certificate pem")
+	}
+	
+	payload := RevokeCertificatePayload{
+		Certificate: base64.RawURLEncoding.EncodeToString(block.Bytes),
+		Reason:      reason,
+	}
+	
+	payloadByte, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	
+	return string(payloadByte), nil
 }
@@ -1,13 +1,54 @@
 package step
 
 import (
+	"context"
+	"crypto"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"io"
+	"math/big"
+	"net/http"
 	"testing"
+	"time"
 )
 
+// 生成自签名证书 (PEM)
+
+func newTestCertificate(t *testing.T, key crypto.Signer, domain string) string {
+	return newTestIssuedCertificate(t, key, domain, nil, nil)
+}
+
+// 生成由 parent 签发的证书 (PEM), parent 为 nil 时为自签名证书
+
+func newTestIssuedCertificate(t *testing.T, key crypto.Signer, commonName string, parent *x509.Certificate, parentKey crypto.Signer) string {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
 func TestParseCertificate(t *testing.T) {
 	cert := `
 -----BEGIN CERTIFICATE-----
@@ -121,3 +162,123 @@ oZ2lS38fL18Aon458fbc0BPHtenfhKj5
 	fmt.Println("Subject: ", c.Subject)
 	
 }
+
+func TestRevokeCertificate(t *testing.T) {
+	accountKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	certKey, err := GeneratePrivateKey(KeyTypeRSA2048)
+	require.NoError(t, err)
+	
+	certificate := newTestCertificate(t, certKey, "www.example.com")
+	block, _ := pem.Decode([]byte(certificate))
+	
+	server := newTestAcmeServer(t)
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/revoke", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		// keyCompromise 使用证书私钥签名并内嵌 jwk, 其他原因使用账户 kid
+		header := jws.Signatures[0].Header
+		verifyKey := accountKey.Public()
+		if header.KeyID == "" {
+			assert.NotNil(t, header.JSONWebKey)
+			verifyKey = certKey.Public()
+		}
+		
+		payloadByte, err := jws.Verify(verifyKey)
+		if !assert.NoError(t, err) {
+			return
+		}
+		
+		var payload RevokeCertificatePayload
+		assert.NoError(t, json.Unmarshal(payloadByte, &payload))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(block.Bytes), payload.Certificate)
+		
+		w.Header().Set("Replay-Nonce", "nonce-2")
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	
+	payload, err := GenerateRevokeCertificatePayload(certificate, RevocationReasonSuperseded)
+	require.NoError(t, err)
+	
+	err = client.RevokeCertificate(context.Background(), server.URL+"/revoke", payload, AccountKey{Kid: server.URL + "/acct/1", PrivateKey: accountKey})
+	require.NoError(t, err)
+	
+	payload, err = GenerateRevokeCertificatePayload(certificate, RevocationReasonKeyCompromise)
+	require.NoError(t, err)
+	
+	err = client.RevokeCertificate(context.Background(), server.URL+"/revoke", payload, AccountKey{PrivateKey: certKey})
+	require.NoError(t, err)
+	
+	_, err = GenerateRevokeCertificatePayload("", RevocationReasonUnspecified)
+	require.Error(t, err)
+}
+
+func TestDownloadCertificates(t *testing.T) {
+	accountKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	// leaf <- intermediate <- root (Root X1), 备用链中 intermediate 由 Legacy Root 交叉签名
+	rootKey, _ := GeneratePrivateKey(KeyTypeEC256)
+	legacyKey, _ := GeneratePrivateKey(KeyTypeEC256)
+	intermediateKey, _ := GeneratePrivateKey(KeyTypeEC256)
+	leafKey, _ := GeneratePrivateKey(KeyTypeEC256)
+	
+	root := parseTestCertificate(t, newTestIssuedCertificate(t, rootKey, "Root X1", nil, nil))
+	legacy := parseTestCertificate(t, newTestIssuedCertificate(t, legacyKey, "Legacy Root", nil, nil))
+	
+	intermediatePem := newTestIssuedCertificate(t, intermediateKey, "Intermediate", root, rootKey)
+	intermediate := parseTestCertificate(t, intermediatePem)
+	crossPem := newTestIssuedCertificate(t, intermediateKey, "Intermediate", legacy, legacyKey)
+	leafPem := newTestIssuedCertificate(t, leafKey, "www.example.com", intermediate, intermediateKey)
+	
+	server := newTestAcmeServer(t)
+	mux := server.Config.Handler.(*http.ServeMux)
+	
+	mux.HandleFunc("/cert/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		w.Header().Add("Link", fmt.Sprintf(`<%s/cert/1/2>;rel="alternate"`, server.URL))
+		w.Header().Add("Link", fmt.Sprintf(`<%s/cert/1/1>;rel="alternate"`, server.URL))
+		fmt.Fprint(w, leafPem+intermediatePem)
+	})
+	
+	// 下载失败的备用证书链被跳过
+	mux.HandleFunc("/cert/1/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce-4")
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"type":"urn:ietf:params:acme:error:serverInternal","detail":"alternate chain unavailable"}`)
+	})
+	
+	mux.HandleFunc("/cert/1/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce-3")
+		fmt.Fprint(w, leafPem+crossPem)
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	chains, err := client.DownloadCertificates(context.Background(), server.URL+"/cert/1",
+		AccountKey{Kid: server.URL + "/acct/1", PrivateKey: accountKey})
+	require.ErrorContains(t, err, "/cert/1/2")
+	require.Len(t, chains, 2)
+	
+	require.Equal(t, chains[0], SelectPreferredChain(chains, ""))
+	require.Equal(t, chains[0], SelectPreferredChain(chains, "Root X1"))
+	require.Equal(t, chains[1], SelectPreferredChain(chains, "Legacy Root"))
+	require.Equal(t, chains[0], SelectPreferredChain(chains, "Unknown Root"), "没有匹配时使用默认证书链")
+}
+
+func parseTestCertificate(t *testing.T, certificate string) *x509.Certificate {
+	block, _ := pem.Decode([]byte(certificate))
+	require.NotNil(t, block)
+	
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
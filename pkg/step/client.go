@@ -0,0 +1,151 @@
+package step
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUserAgent   = "auto-cert"
+	defaultHttpTimeout = 30 * time.Second
+)
+
+// Client ACME 客户端, 持有 directory 地址、http.Client 以及 User-Agent
+// 所有请求均通过 context 传递, 以便 gin 请求取消或 cron 退出时可以中断请求
+
+type Client struct {
+	directoryUrl string
+	httpClient   *http.Client
+	userAgent    string
+	
+	mu        sync.Mutex
+	directory *DirectoryResponse
+	nonces    noncePool
+}
+
+// AccountKey 签名 JWS 使用的账户信息, Kid 为账户 url, 为空时在 JWS 中内嵌 jwk (仅 newAccount 使用)
+
+type AccountKey struct {
+	Kid        string
+	PrivateKey crypto.Signer
+}
+
+// httpClient 为 nil 时使用默认超时的 http.Client, userAgent 为空时使用 auto-cert
+
+func NewClient(directoryUrl string, httpClient *http.Client, userAgent string) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHttpTimeout}
+	}
+	
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	
+	return &Client{
+		directoryUrl: directoryUrl,
+		httpClient:   httpClient,
+		userAgent:    userAgent,
+	}
+}
+
+func (client *Client) DirectoryUrl() string {
+	return client.directoryUrl
+}
+
+func (client *Client) do(ctx context.Context, method, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	
+	req.Header.Set("User-Agent", client.userAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	
+	return client.httpClient.Do(req)
+}
+
+func (client *Client) get(ctx context.Context, url string) (*http.Response, error) {
+	return client.do(ctx, http.MethodGet, url, "", nil)
+}
+
+func (client *Client) head(ctx context.Context, url string) (*http.Response, error) {
+	return client.do(ctx, http.MethodHead, url, "", nil)
+}
+
+// ACME 所有 POST 请求均为 JWS, Content-Type 必须为 application/jose+json
+
+func (client *Client) post(ctx context.Context, url string, req []byte) (*http.Response, error) {
+	return client.do(ctx, http.MethodPost, url, "application/jose+json", bytes.NewBuffer(req))
+}
+
+// postJWS 使用池中的 nonce 签名并发送请求, 并收集响应中的 Replay-Nonce
+// 非 2xx 响应返回 *Problem, 服务端返回 badNonce 时使用新的 nonce 重新签名并重试一次
+// https://datatracker.ietf.org/doc/html/rfc8555#section-6.5
+
+func (client *Client) postJWS(ctx context.Context, url, payload string, key AccountKey) (*http.Response, []byte, error) {
+	for retry := 0; ; retry++ {
+		nonce, err := client.nonce(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		
+		signed, err := GetSignature(url, nonce, payload, key.Kid, key.PrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		
+		resp, err := client.post(ctx, url, []byte(signed.FullSerialize()))
+		if err != nil {
+			return nil, nil, err
+		}
+		
+		respBodyByte, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		
+		client.nonces.Push(resp.Header.Get("Replay-Nonce"))
+		
+		err = checkResponse(resp, respBodyByte)
+		if retry == 0 && IsBadNonce(err) {
+			continue
+		}
+		
+		return resp, respBodyByte, err
+	}
+}
+
+// 解析响应头 Link 中指定 rel 的 url, 例如 Link: <https://example.com/acme/orders/rzGoeA?cursor=2>;rel="next"
+// https://datatracker.ietf.org/doc/html/rfc8288
+
+func getLinks(header http.Header, rel string) []string {
+	var links []string
+	
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			url := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(url, "<") || !strings.HasSuffix(url, ">") {
+				continue
+			}
+			
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if strings.EqualFold(param, `rel="`+rel+`"`) || strings.EqualFold(param, "rel="+rel) {
+					links = append(links, strings.Trim(url, "<>"))
+				}
+			}
+		}
+	}
+	
+	return links
+}
@@ -0,0 +1,52 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestAcmeServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "auto-cert-test", r.Header.Get("User-Agent"))
+		fmt.Fprintf(w, `{"newNonce":"%[1]s/nonce","newAccount":"%[1]s/account","newOrder":"%[1]s/order","revokeCert":"%[1]s/revoke"}`, server.URL)
+	})
+	
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce-1")
+	})
+	
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientDirectory(t *testing.T) {
+	server := newTestAcmeServer(t)
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	
+	directory, err := client.Directory(context.Background())
+	require.NoError(t, err, "获取Directory失败")
+	require.Equal(t, server.URL+"/nonce", directory.NewNonce)
+	
+	nonce, err := client.GetNonce(context.Background(), directory.NewNonce)
+	require.NoError(t, err, "获取Nonce失败")
+	require.Equal(t, "nonce-1", nonce)
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	server := newTestAcmeServer(t)
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	
+	_, err := client.Directory(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
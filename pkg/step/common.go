@@ -1,5 +1,7 @@
 package step
 
+import "net"
+
 const (
 	//let Encrypt 正式环境 API
 	LetEncryptDirectoryProdUrl = "https://acme-v02.api.letsencrypt.org/directory"
@@ -12,3 +14,27 @@ type Identifier struct {
 	Type  string `json:"type"`  // required
 	Value string `json:"value"` // required
 }
+
+// 标识类型, ip 标识见 RFC 8738
+// https://datatracker.ietf.org/doc/html/rfc8738
+
+const (
+	IdentifierTypeDNS = "dns"
+	IdentifierTypeIP  = "ip"
+)
+
+// IP 字面量生成 ip 标识 (IPv6 使用 RFC 5952 规范格式), 其余生成 dns 标识
+
+func NewIdentifier(value string) Identifier {
+	if ip := net.ParseIP(value); ip != nil {
+		return Identifier{Type: IdentifierTypeIP, Value: ip.String()}
+	}
+	
+	return Identifier{Type: IdentifierTypeDNS, Value: value}
+}
+
+// ip 标识不允许使用 dns-01 验证, 只能使用 http-01 或 tls-alpn-01
+
+func (identifier Identifier) IsIP() bool {
+	return identifier.Type == IdentifierTypeIP
+}
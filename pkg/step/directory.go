@@ -3,28 +3,30 @@ package step
 // 1
 
 import (
+	"context"
 	"encoding/json"
 	"io"
-	"net/http"
 )
 
 // https://datatracker.ietf.org/doc/html/rfc8555#section-6.4.1
 
 type DirectoryResponse struct {
-	NewNonce   string                `json:"newNonce"`             // 业务流程（如申请、撤销）时候需要预请求获取一次性令牌的一个接口
-	NewAccount string                `json:"newAccount,omitempty"` // 上传 account key 时候调用
-	NewOrder   string                `json:"newOrder"`             // 下单的接口
-	NewAuthz   string                `json:"newAuthz,omitempty"`   // If the ACME server does not implement pre-authorization, it MUST omit the "newAuthz" field of the directory.
-	RevokeCert string                `json:"revokeCert"`           // 撤销证书的接口
-	KeyChange  string                `json:"keyChange,omitempty"`  // 更换 KeyPair 的接口
-	Meta       directoryResponseMeta `json:"meta,omitempty"`
+	NewNonce    string                `json:"newNonce"`              // 业务流程（如申请、撤销）时候需要预请求获取一次性令牌的一个接口
+	NewAccount  string                `json:"newAccount,omitempty"`  // 上传 account key 时候调用
+	NewOrder    string                `json:"newOrder"`              // 下单的接口
+	NewAuthz    string                `json:"newAuthz,omitempty"`    // If the ACME server does not implement pre-authorization, it MUST omit the "newAuthz" field of the directory.
+	RevokeCert  string                `json:"revokeCert"`            // 撤销证书的接口
+	KeyChange   string                `json:"keyChange,omitempty"`   // 更换 KeyPair 的接口
+	RenewalInfo string                `json:"renewalInfo,omitempty"` // ARI 续期信息接口, RFC 9773
+	Meta        directoryResponseMeta `json:"meta,omitempty"`
 }
 
 type directoryResponseMeta struct {
-	TermsOfService          string   `json:"termsOfService"`          // optional, 标识当前的 URL 服务条款
-	Website                 string   `json:"website"`                 // optional, An HTTP or HTTPS URL locating a website providing more information about the ACME server.
-	CaaIdentities           []string `json:"caaIdentities"`           // optional, 用于 【RFC6844】中定义的CAA记录验证
-	ExternalAccountRequired bool     `json:"externalAccountRequired"` // optional, 如果该字段是 存在并设置为“true”，则 CA 要求所有 newAccount 请求包含“externalAccountBinding”字段 将新帐户与外部帐户关联。
+	TermsOfService          string            `json:"termsOfService"`          // optional, 标识当前的 URL 服务条款
+	Website                 string            `json:"website"`                 // optional, An HTTP or HTTPS URL locating a website providing more information about the ACME server.
+	CaaIdentities           []string          `json:"caaIdentities"`           // optional, 用于 【RFC6844】中定义的CAA记录验证
+	ExternalAccountRequired bool              `json:"externalAccountRequired"` // optional, 如果该字段是 存在并设置为“true”，则 CA 要求所有 newAccount 请求包含“externalAccountBinding”字段 将新帐户与外部帐户关联。
+	Profiles                map[string]string `json:"profiles,omitempty"`      // optional, CA 支持的证书配置, 名称 -> 描述, draft-ietf-acme-profiles
 }
 
 func (directoryResponse DirectoryResponse) String() string {
@@ -32,12 +34,26 @@ func (directoryResponse DirectoryResponse) String() string {
 	return string(bytes)
 }
 
-// 获取 directory 信息
+// CA 是否提供该证书配置
 
-func Directory(directoryUrl string) (DirectoryResponse, error) {
+func (directoryResponse DirectoryResponse) SupportsProfile(profile string) bool {
+	_, ok := directoryResponse.Meta.Profiles[profile]
+	return ok
+}
+
+// 获取 directory 信息, 获取成功后缓存在 Client 中
+
+func (client *Client) Directory(ctx context.Context) (DirectoryResponse, error) {
 	var directoryResponse DirectoryResponse
 	
-	resp, err := http.Get(directoryUrl)
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	
+	if client.directory != nil {
+		return *client.directory, nil
+	}
+	
+	resp, err := client.get(ctx, client.directoryUrl)
 	if err != nil {
 		return directoryResponse, err
 	}
@@ -51,10 +67,16 @@ func Directory(directoryUrl string) (DirectoryResponse, error) {
 		return directoryResponse, err
 	}
 	
+	err = checkResponse(resp, respBodyByte)
+	if err != nil {
+		return directoryResponse, err
+	}
+	
 	err = json.Unmarshal(respBodyByte, &directoryResponse)
 	if err != nil {
 		return directoryResponse, err
 	}
 	
+	client.directory = &directoryResponse
 	return directoryResponse, nil
 }
@@ -1,10 +1,11 @@
 package step
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"github.com/miekg/dns"
 	"net"
+	"strings"
 	"time"
 )
 
@@ -54,14 +55,14 @@ func createDNSMsg(fqdn string, rtype uint16, recursive bool) *dns.Msg {
 	return m
 }
 
-func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool) (*dns.Msg, error) {
+func dnsQuery(ctx context.Context, fqdn string, rtype uint16, nameservers []string, recursive bool) (*dns.Msg, error) {
 	m := createDNSMsg(fqdn, rtype, recursive)
 	
 	var in *dns.Msg
 	var err error
 	
 	for _, ns := range nameservers {
-		in, err = sendDNSQuery(m, ns)
+		in, err = sendDNSQuery(ctx, m, ns)
 		if err == nil && len(in.Answer) > 0 {
 			break
 		}
@@ -69,14 +70,14 @@ func dnsQuery(fqdn string, rtype uint16, nameservers []string, recursive bool) (
 	return in, err
 }
 
-func sendDNSQuery(m *dns.Msg, ns string) (*dns.Msg, error) {
+func sendDNSQuery(ctx context.Context, m *dns.Msg, ns string) (*dns.Msg, error) {
 	udp := &dns.Client{Net: "udp", Timeout: dnsTimeout}
-	in, _, err := udp.Exchange(m, ns)
+	in, _, err := udp.ExchangeContext(ctx, m, ns)
 	
 	if in != nil && in.Truncated {
 		tcp := &dns.Client{Net: "tcp", Timeout: dnsTimeout}
 		// If the TCP request succeeds, the err will reset to nil
-		in, _, err = tcp.Exchange(m, ns)
+		in, _, err = tcp.ExchangeContext(ctx, m, ns)
 	}
 	
 	return in, err
@@ -95,46 +96,34 @@ func updateDomainWithCName(r *dns.Msg, fqdn string) string {
 	return fqdn
 }
 
+// 通过递归服务器检查一次 TXT 记录, 任意一个服务器返回期望值即通过
+// 递归服务器可能存在缓存, 验证前的传播检查使用 CheckTxtPropagation
+
 func VerifyTxtRecord(fqdn, value string, ns []string) error {
 	fqdn = dns.Fqdn(fqdn)
-	m := createDNSMsg(fqdn, dns.TypeTXT, true)
 	
 	if len(ns) == 0 {
 		ns = defaultNameservers
 	}
 	
-	mx, err := sendDNSQuery(m, ns[0])
-	if err != nil {
-		fmt.Printf("发送dnsQuery失败, err: %s\n", err)
-		return err
-	}
-	m = mx
-	
-	fmt.Printf("发送dnsQuery成功\n")
-	
-	for _, rr := range m.Answer {
-		if cn, ok := rr.(*dns.TXT); ok {
-			if cn.Hdr.Name == fqdn {
-				//if  value == cn.Txt[0]
-				check := false
-				for _, answerTxt := range cn.Txt {
-					fmt.Printf("校验Txt值: %s\n", answerTxt)
-					if answerTxt == value {
-						check = true
-					}
-				}
-				
-				if check {
-					fmt.Printf("验证FQDN: %s Txt Value: %s成功\n", fqdn, value)
-					return nil
-				}
-				
-				fmt.Printf("验证FQDN: %s Txt Value: %s失败\n", fqdn, value)
+	var err error
+	for _, nameserver := range ns {
+		var r *dns.Msg
+		r, err = sendDNSQuery(context.Background(), createDNSMsg(fqdn, dns.TypeTXT, true), nameserver)
+		if err != nil {
+			continue
+		}
+		
+		for _, rr := range r.Answer {
+			if txt, ok := rr.(*dns.TXT); ok && txt.Hdr.Name == fqdn && strings.Join(txt.Txt, "") == value {
+				return nil
 			}
-		} else {
-			fmt.Printf("answer 内容非Txt格式\n")
 		}
 	}
 	
+	if err != nil {
+		return err
+	}
+	
 	return errors.New("not match")
 }
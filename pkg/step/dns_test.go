@@ -1,6 +1,7 @@
 package step
 
 import (
+	"context"
 	"fmt"
 	"github.com/miekg/dns"
 	"github.com/stretchr/testify/require"
@@ -26,7 +27,7 @@ func TestCreateDNSMsg(t *testing.T) {
 	fqdn := dns.Fqdn("www.baidu.com.")
 	fmt.Println("fqdn: ", fqdn)
 	m := createDNSMsg(fqdn, dns.TypeA, true)
-	m, err := sendDNSQuery(m, "8.8.8.8:53")
+	m, err := sendDNSQuery(context.Background(), m, "8.8.8.8:53")
 	require.NoError(t, err, "TestCreateDNSMsg sendDNSQuery 失败")
 	
 	fmt.Println("Answer: ", m.Answer)
@@ -49,7 +50,7 @@ func TestDNSRecord(t *testing.T) {
 	m.SetEdns0(4096, false)
 	m.RecursionDesired = true
 	
-	m, err := sendDNSQuery(m, "8.8.8.8:53")
+	m, err := sendDNSQuery(context.Background(), m, "8.8.8.8:53")
 	require.NoError(t, err, "TestCreateDNSMsg sendDNSQuery 失败")
 	
 	for _, rr := range m.Answer {
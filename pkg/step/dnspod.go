@@ -0,0 +1,317 @@
+package step
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 腾讯云 DNSPod, 使用 API 3.0 TC3-HMAC-SHA256 签名
+// https://cloud.tencent.com/document/api/1427/56189
+
+const (
+	defaultDnspodEndpoint   = "https://dnspod.tencentcloudapi.com/"
+	defaultDnspodTTL        = 600
+	defaultDnspodRecordLine = "默认"
+	dnspodService           = "dnspod"
+	dnspodApiVersion        = "2021-03-23"
+	dnspodPageSize          = 3000
+)
+
+type DnspodConfig struct {
+	SecretId            string
+	SecretKey           string
+	Endpoint            string // 为空时使用 defaultDnspodEndpoint
+	RecordLine          string // 记录线路, 为空时使用 "默认"
+	TTL                 int    // 为 0 时使用 defaultDnspodTTL
+	HttpClient          *http.Client
+	PropagationTimeout  time.Duration
+	PropagationInterval time.Duration
+}
+
+type DnspodProvider struct {
+	config DnspodConfig
+	host   string
+}
+
+func NewDnspodProvider(config DnspodConfig) (*DnspodProvider, error) {
+	if config.SecretId == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("dnspod: secretId and secretKey are required")
+	}
+	
+	if config.Endpoint == "" {
+		config.Endpoint = defaultDnspodEndpoint
+	}
+	
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("dnspod: %w", err)
+	}
+	
+	if config.RecordLine == "" {
+		config.RecordLine = defaultDnspodRecordLine
+	}
+	
+	if config.TTL == 0 {
+		config.TTL = defaultDnspodTTL
+	}
+	
+	if config.HttpClient == nil {
+		config.HttpClient = &http.Client{Timeout: defaultHttpTimeout}
+	}
+	
+	return &DnspodProvider{config: config, host: endpoint.Host}, nil
+}
+
+type dnspodError struct {
+	RequestId string
+	Code      string
+	Message   string
+}
+
+func (e *dnspodError) Error() string {
+	return fmt.Sprintf("dnspod: %s: %s (RequestId: %s)", e.Code, e.Message, e.RequestId)
+}
+
+type dnspodRecord struct {
+	RecordId uint64 `json:"RecordId"`
+	Name     string `json:"Name"`
+	Type     string `json:"Type"`
+	Line     string `json:"Line"`
+	Value    string `json:"Value"`
+}
+
+// 记录已存在时 CreateRecord 返回 InvalidParameter.DomainRecordExist, 视为添加成功
+
+func (provider *DnspodProvider) Present(ctx context.Context, fqdn, value string) error {
+	zone, subDomain, err := provider.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	
+	err = provider.request(ctx, "CreateRecord", map[string]interface{}{
+		"Domain":     zone,
+		"SubDomain":  subDomain,
+		"RecordType": "TXT",
+		"RecordLine": provider.config.RecordLine,
+		"Value":      value,
+		"TTL":        provider.config.TTL,
+	}, nil)
+	if e, ok := err.(*dnspodError); ok && e.Code == "InvalidParameter.DomainRecordExist" {
+		return nil
+	}
+	
+	return err
+}
+
+// 只删除主机记录和值均匹配的记录, 记录不存在时返回成功
+
+func (provider *DnspodProvider) CleanUp(ctx context.Context, fqdn, value string) error {
+	zone, subDomain, err := provider.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	
+	var resp struct {
+		RecordList []dnspodRecord `json:"RecordList"`
+	}
+	err = provider.request(ctx, "DescribeRecordList", map[string]interface{}{
+		"Domain":     zone,
+		"Subdomain":  subDomain,
+		"RecordType": "TXT",
+	}, &resp)
+	if e, ok := err.(*dnspodError); ok && e.Code == "ResourceNotFound.NoDataOfRecord" {
+		return nil
+	}
+	
+	if err != nil {
+		return err
+	}
+	
+	for _, record := range resp.RecordList {
+		if record.Name != subDomain || record.Type != "TXT" || record.Value != value {
+			continue
+		}
+		
+		err = provider.request(ctx, "DeleteRecord", map[string]interface{}{
+			"Domain":   zone,
+			"RecordId": record.RecordId,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+func (provider *DnspodProvider) Timeout() (time.Duration, time.Duration) {
+	return provider.config.PropagationTimeout, provider.config.PropagationInterval
+}
+
+// 在账户已添加的域名中按后缀最长匹配, 返回域名及主机记录
+
+func (provider *DnspodProvider) findZone(ctx context.Context, fqdn string) (string, string, error) {
+	name := strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	
+	zone := ""
+	for offset := 0; ; offset += dnspodPageSize {
+		var resp struct {
+			DomainCountInfo struct {
+				AllTotal int `json:"AllTotal"`
+			} `json:"DomainCountInfo"`
+			DomainList []struct {
+				Name string `json:"Name"`
+			} `json:"DomainList"`
+		}
+		err := provider.request(ctx, "DescribeDomainList", map[string]interface{}{
+			"Offset": offset,
+			"Limit":  dnspodPageSize,
+		}, &resp)
+		if err != nil {
+			return "", "", err
+		}
+		
+		for _, domain := range resp.DomainList {
+			domainName := strings.ToLower(domain.Name)
+			if name != domainName && !strings.HasSuffix(name, "."+domainName) {
+				continue
+			}
+			
+			if len(domainName) > len(zone) {
+				zone = domainName
+			}
+		}
+		
+		if offset+dnspodPageSize >= resp.DomainCountInfo.AllTotal {
+			break
+		}
+	}
+	
+	if zone == "" {
+		return "", "", fmt.Errorf("dnspod: could not find domain for %s", fqdn)
+	}
+	
+	subDomain := strings.TrimSuffix(strings.TrimSuffix(name, zone), ".")
+	if subDomain == "" {
+		subDomain = "@"
+	}
+	
+	return zone, subDomain, nil
+}
+
+// 错误通过 Response.Error 返回, HTTP 状态码为 200
+
+func (provider *DnspodProvider) request(ctx context.Context, action string, params map[string]interface{}, v interface{}) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.config.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", defaultUserAgent)
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", dnspodApiVersion)
+	req.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("Authorization", TC3Authorization(dnspodService, provider.config.SecretId, provider.config.SecretKey, provider.host, payload, timestamp))
+	
+	resp, err := provider.config.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("dnspod: %s: unexpected status %d", action, resp.StatusCode)
+	}
+	
+	var result struct {
+		Response json.RawMessage `json:"Response"`
+	}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return err
+	}
+	
+	var respError struct {
+		RequestId string `json:"RequestId"`
+		Error     *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	}
+	err = json.Unmarshal(result.Response, &respError)
+	if err != nil {
+		return err
+	}
+	
+	if respError.Error != nil {
+		return &dnspodError{RequestId: respError.RequestId, Code: respError.Error.Code, Message: respError.Error.Message}
+	}
+	
+	if v == nil {
+		return nil
+	}
+	
+	return json.Unmarshal(result.Response, v)
+}
+
+// TC3-HMAC-SHA256 签名, 签名 content-type 和 host 两个请求头, service 为产品名 (如 dnspod)
+// https://cloud.tencent.com/document/api/1427/56189
+
+func TC3Authorization(service, secretId, secretKey, host string, payload []byte, timestamp int64) string {
+	date := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	signedHeaders := "content-type;host"
+	payloadHash := sha256.Sum256(payload)
+	
+	canonicalRequest := strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		"content-type:application/json; charset=utf-8\nhost:" + host + "\n",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	
+	credentialScope := date + "/" + service + "/tc3_request"
+	stringToSign := strings.Join([]string{
+		"TC3-HMAC-SHA256",
+		strconv.FormatInt(timestamp, 10),
+		credentialScope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+	
+	secretDate := hmacSha256([]byte("TC3"+secretKey), date)
+	secretService := hmacSha256(secretDate, service)
+	secretSigning := hmacSha256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSha256(secretSigning, stringToSign))
+	
+	return fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", secretId, credentialScope, signedHeaders, signature)
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,159 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// 腾讯云签名文档中的示例 (云服务器 DescribeInstances)
+// https://cloud.tencent.com/document/api/213/30654
+
+func TestTC3Authorization(t *testing.T) {
+	payload := `{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`
+	authorization := TC3Authorization("cvm", "AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******", "Gu5t9xGARNpq86cd98joQYCN3*******", "cvm.tencentcloudapi.com", []byte(payload), 1551113065)
+	require.Equal(t, "TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******/2019-02-25/cvm/tc3_request, SignedHeaders=content-type;host, "+
+		"Signature=2230eefd229f582d8b1b891af7107b91597240707d778ab3738f756258d7652c", authorization)
+}
+
+// 模拟 DNSPod API 3.0, 校验签名后按 X-TC-Action 处理请求
+
+type testDnspodServer struct {
+	mu      sync.Mutex
+	nextId  uint64
+	records map[uint64]dnspodRecord
+	domains map[uint64]string // RecordId -> Domain
+}
+
+func (server *testDnspodServer) writeError(w http.ResponseWriter, code, message string) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Response": map[string]interface{}{
+			"Error":     map[string]string{"Code": code, "Message": message},
+			"RequestId": "1",
+		},
+	})
+}
+
+func (server *testDnspodServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-TC-Timestamp"), 10, 64)
+	if r.Header.Get("Authorization") != TC3Authorization(dnspodService, "AKIDtest", "test-secret-key", r.Host, body, timestamp) {
+		server.writeError(w, "AuthFailure.SignatureFailure", "signature mismatch")
+		return
+	}
+	
+	var params struct {
+		Domain     string
+		SubDomain  string
+		Subdomain  string
+		RecordType string
+		RecordLine string
+		RecordId   uint64
+		Value      string
+		Offset     int
+	}
+	json.Unmarshal(body, &params)
+	
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	
+	var resp interface{}
+	switch r.Header.Get("X-TC-Action") {
+	case "DescribeDomainList":
+		// 每页 3000 条, 第二页返回 corp.example.com
+		domain := "example.com"
+		if params.Offset == dnspodPageSize {
+			domain = "corp.example.com"
+		}
+		resp = map[string]interface{}{
+			"DomainCountInfo": map[string]int{"AllTotal": dnspodPageSize + 1},
+			"DomainList":      []map[string]string{{"Name": domain}},
+		}
+	case "CreateRecord":
+		for _, record := range server.records {
+			if record.Name == params.SubDomain && record.Value == params.Value {
+				server.writeError(w, "InvalidParameter.DomainRecordExist", "记录已经存在，无需再次添加。")
+				return
+			}
+		}
+		
+		server.nextId++
+		server.records[server.nextId] = dnspodRecord{RecordId: server.nextId, Name: params.SubDomain, Type: params.RecordType, Line: params.RecordLine, Value: params.Value}
+		server.domains[server.nextId] = params.Domain
+		resp = map[string]interface{}{"RecordId": server.nextId}
+	case "DescribeRecordList":
+		var records []dnspodRecord
+		for recordId, record := range server.records {
+			if server.domains[recordId] == params.Domain && record.Name == params.Subdomain {
+				records = append(records, record)
+			}
+		}
+		
+		if len(records) == 0 {
+			server.writeError(w, "ResourceNotFound.NoDataOfRecord", "记录列表为空。")
+			return
+		}
+		resp = map[string]interface{}{"RecordList": records}
+	case "DeleteRecord":
+		delete(server.records, params.RecordId)
+		resp = map[string]interface{}{}
+	default:
+		server.writeError(w, "InvalidAction", "unknown action")
+		return
+	}
+	
+	json.NewEncoder(w).Encode(map[string]interface{}{"Response": resp})
+}
+
+func TestDnspodProvider(t *testing.T) {
+	handler := &testDnspodServer{records: make(map[uint64]dnspodRecord), domains: make(map[uint64]string)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	
+	provider, err := NewDnspodProvider(DnspodConfig{SecretId: "AKIDtest", SecretKey: "test-secret-key", Endpoint: server.URL + "/"})
+	require.NoError(t, err)
+	
+	// 子域名使用最长匹配的域名
+	ctx := context.Background()
+	zone, subDomain, err := provider.findZone(ctx, "_acme-challenge.www.corp.example.com.")
+	require.NoError(t, err)
+	require.Equal(t, "corp.example.com", zone)
+	require.Equal(t, "_acme-challenge.www", subDomain)
+	
+	_, _, err = provider.findZone(ctx, "_acme-challenge.example.org.")
+	require.Error(t, err)
+	
+	// 未配置记录线路时使用 "默认", 重复添加视为成功
+	fqdn := "_acme-challenge.example.com."
+	require.NoError(t, provider.Present(ctx, fqdn, "value-1"))
+	require.NoError(t, provider.Present(ctx, fqdn, "value-2"))
+	require.NoError(t, provider.Present(ctx, fqdn, "value-1"))
+	require.Len(t, handler.records, 2)
+	require.Equal(t, "example.com", handler.domains[1])
+	require.Equal(t, dnspodRecord{RecordId: 1, Name: "_acme-challenge", Type: "TXT", Line: "默认", Value: "value-1"}, handler.records[1])
+	
+	// 删除时只删除对应的值, 记录不存在时返回成功
+	require.NoError(t, provider.CleanUp(ctx, fqdn, "value-1"))
+	require.Len(t, handler.records, 1)
+	require.Equal(t, "value-2", handler.records[2].Value)
+	require.NoError(t, provider.CleanUp(ctx, "_acme-challenge.www.example.com.", "value-1"))
+	
+	// 指定记录线路
+	provider, err = NewDnspodProvider(DnspodConfig{SecretId: "AKIDtest", SecretKey: "test-secret-key", Endpoint: server.URL + "/", RecordLine: "境内"})
+	require.NoError(t, err)
+	require.NoError(t, provider.Present(ctx, fqdn, "value-3"))
+	require.Equal(t, "境内", handler.records[3].Line)
+	
+	// 签名错误
+	provider, err = NewDnspodProvider(DnspodConfig{SecretId: "AKIDtest", SecretKey: "wrong-secret-key", Endpoint: server.URL + "/"})
+	require.NoError(t, err)
+	err = provider.Present(ctx, fqdn, "value-4")
+	require.Error(t, err)
+	require.Equal(t, "AuthFailure.SignatureFailure", err.(*dnspodError).Code)
+}
@@ -0,0 +1,91 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DNSProvider 自动添加/删除 dns-01 验证的 TXT 记录
+// fqdn 为需要添加 TXT 记录的名称 (以 . 结尾, 已跟随 CNAME), 同一 fqdn 可能同时存在多个 TXT 值, Present/CleanUp 只处理 value 对应的记录
+// 定时任务每次检查 pending 订单时都会调用 Present, 记录已存在时应直接返回成功
+
+type DNSProvider interface {
+	Present(ctx context.Context, fqdn, value string) error
+	CleanUp(ctx context.Context, fqdn, value string) error
+	// 等待传播的最长时间和轮询间隔, 为 0 时使用全局配置
+	Timeout() (timeout, interval time.Duration)
+}
+
+// DNSProviders 按名称保存 DNSProvider, 并记录域名后缀与 provider 的对应关系
+
+type DNSProviders struct {
+	providers map[string]DNSProvider
+	domains   map[string]string // 域名后缀 -> provider 名称
+}
+
+func NewDNSProviders() *DNSProviders {
+	return &DNSProviders{
+		providers: make(map[string]DNSProvider),
+		domains:   make(map[string]string),
+	}
+}
+
+// 注册 provider, domains 为默认使用该 provider 的域名后缀
+
+func (dnsProviders *DNSProviders) Register(name string, provider DNSProvider, domains ...string) error {
+	if name == "" {
+		return fmt.Errorf("dns provider name is empty")
+	}
+	
+	if _, ok := dnsProviders.providers[name]; ok {
+		return fmt.Errorf("dns provider %s already registered", name)
+	}
+	
+	for _, domain := range domains {
+		domain = normalizeProviderDomain(domain)
+		if exist, ok := dnsProviders.domains[domain]; ok {
+			return fmt.Errorf("domain %s already assigned to dns provider %s", domain, exist)
+		}
+		dnsProviders.domains[domain] = name
+	}
+	
+	dnsProviders.providers[name] = provider
+	return nil
+}
+
+func (dnsProviders *DNSProviders) Get(name string) (DNSProvider, bool) {
+	provider, ok := dnsProviders.providers[name]
+	return provider, ok
+}
+
+// 查找域名使用的 provider, 优先按域名后缀最长匹配, 其次使用 name 指定的 provider (如账户配置)
+
+func (dnsProviders *DNSProviders) Lookup(domain, name string) (string, DNSProvider, bool) {
+	domain = normalizeProviderDomain(domain)
+	
+	matched := ""
+	for suffix, providerName := range dnsProviders.domains {
+		if domain != suffix && !strings.HasSuffix(domain, "."+suffix) {
+			continue
+		}
+		
+		if len(suffix) > len(matched) {
+			matched = suffix
+			name = providerName
+		}
+	}
+	
+	provider, ok := dnsProviders.providers[name]
+	if !ok {
+		return "", nil, false
+	}
+	
+	return name, provider, true
+}
+
+func normalizeProviderDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return strings.TrimPrefix(domain, "*.")
+}
@@ -0,0 +1,52 @@
+package step
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testDNSProvider struct{}
+
+func (testDNSProvider) Present(ctx context.Context, fqdn, value string) error {
+	return nil
+}
+
+func (testDNSProvider) CleanUp(ctx context.Context, fqdn, value string) error {
+	return nil
+}
+
+func (testDNSProvider) Timeout() (time.Duration, time.Duration) {
+	return 0, 0
+}
+
+func TestDNSProviders(t *testing.T) {
+	dnsProviders := NewDNSProviders()
+	require.NoError(t, dnsProviders.Register("internal", testDNSProvider{}, "corp.example.com"))
+	require.NoError(t, dnsProviders.Register("public", testDNSProvider{}, "Example.com."))
+	require.NoError(t, dnsProviders.Register("account", testDNSProvider{}))
+	
+	require.Error(t, dnsProviders.Register("public", testDNSProvider{}))
+	require.Error(t, dnsProviders.Register("other", testDNSProvider{}, "example.com"))
+	
+	// 域名后缀最长匹配, 通配符域名使用主域名匹配
+	name, _, ok := dnsProviders.Lookup("www.corp.example.com", "account")
+	require.True(t, ok)
+	require.Equal(t, "internal", name)
+	
+	name, _, ok = dnsProviders.Lookup("*.example.com", "")
+	require.True(t, ok)
+	require.Equal(t, "public", name)
+	
+	name, _, ok = dnsProviders.Lookup("notexample.com", "")
+	require.False(t, ok)
+	
+	// 未匹配域名后缀时使用账户指定的 provider
+	name, _, ok = dnsProviders.Lookup("example.org", "account")
+	require.True(t, ok)
+	require.Equal(t, "account", name)
+	
+	_, ok = dnsProviders.Get("unknown")
+	require.False(t, ok)
+}
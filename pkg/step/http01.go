@@ -0,0 +1,74 @@
+package step
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// http-01 验证, CA 通过 80 端口访问 /.well-known/acme-challenge/{token} 获取 key authorization
+// https://datatracker.ietf.org/doc/html/rfc8555#section-8.3
+
+const http01ChallengePath = "/.well-known/acme-challenge/"
+
+func Http01ChallengePath(token string) string {
+	return http01ChallengePath + token
+}
+
+// Http01Solver 保存待验证的 key authorization, 并作为 http.Handler 响应 CA 的验证请求
+
+type Http01Solver struct {
+	mu                sync.RWMutex
+	keyAuthorizations map[string]string // token -> key authorization
+}
+
+func NewHttp01Solver() *Http01Solver {
+	return &Http01Solver{
+		keyAuthorizations: make(map[string]string),
+	}
+}
+
+func (solver *Http01Solver) Present(token, keyAuthorization string) {
+	solver.mu.Lock()
+	defer solver.mu.Unlock()
+	
+	solver.keyAuthorizations[token] = keyAuthorization
+}
+
+// 验证结束后移除 token
+
+func (solver *Http01Solver) CleanUp(token string) {
+	solver.mu.Lock()
+	defer solver.mu.Unlock()
+	
+	delete(solver.keyAuthorizations, token)
+}
+
+func (solver *Http01Solver) KeyAuthorization(token string) (string, bool) {
+	solver.mu.RLock()
+	defer solver.mu.RUnlock()
+	
+	keyAuthorization, ok := solver.keyAuthorizations[token]
+	return keyAuthorization, ok
+}
+
+func (solver *Http01Solver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	
+	if !strings.HasPrefix(r.URL.Path, http01ChallengePath) {
+		http.NotFound(w, r)
+		return
+	}
+	
+	keyAuthorization, ok := solver.KeyAuthorization(strings.TrimPrefix(r.URL.Path, http01ChallengePath))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write([]byte(keyAuthorization))
+}
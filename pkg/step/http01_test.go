@@ -0,0 +1,42 @@
+package step
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHttp01Solver(t *testing.T) {
+	solver := NewHttp01Solver()
+	server := httptest.NewServer(solver)
+	defer server.Close()
+	
+	solver.Present("token-1", "token-1.thumbprint")
+	
+	resp, err := http.Get(server.URL + Http01ChallengePath("token-1"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, 200, resp.StatusCode)
+	require.Equal(t, "token-1.thumbprint", string(body))
+	
+	resp, err = http.Get(server.URL + Http01ChallengePath("token-2"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 404, resp.StatusCode)
+	
+	resp, err = http.Post(server.URL+Http01ChallengePath("token-1"), "text/plain", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 405, resp.StatusCode)
+	
+	// 验证结束后移除 token
+	solver.CleanUp("token-1")
+	resp, err = http.Get(server.URL + Http01ChallengePath("token-1"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, 404, resp.StatusCode)
+}
@@ -0,0 +1,67 @@
+package step
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// 账户私钥类型
+
+type KeyType string
+
+const (
+	KeyTypeRSA2048 KeyType = "RSA2048"
+	KeyTypeRSA3072 KeyType = "RSA3072"
+	KeyTypeRSA4096 KeyType = "RSA4096"
+	KeyTypeEC256   KeyType = "EC256"
+	KeyTypeEC384   KeyType = "EC384"
+)
+
+func GeneratePrivateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyTypeEC256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeEC384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	}
+	
+	return nil, fmt.Errorf("unsupported key type: %s", keyType)
+}
+
+// 根据私钥类型选择 JWS 签名算法
+// https://datatracker.ietf.org/doc/html/rfc8555#section-6.2
+
+func signatureAlgorithm(privateKey crypto.Signer) (jose.SignatureAlgorithm, error) {
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return jose.RS256, nil
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		}
+		return "", fmt.Errorf("unsupported ecdsa curve: %s", key.Curve.Params().Name)
+	}
+	
+	return "", fmt.Errorf("unsupported private key type: %T", privateKey)
+}
+
+// 检查私钥能否用于签名 JWS, 只支持 RSA 和 P-256/P-384 ECDSA, 其他类型 (如 ed25519) 返回错误
+
+func CheckAccountKey(privateKey crypto.Signer) error {
+	_, err := signatureAlgorithm(privateKey)
+	return err
+}
@@ -4,12 +4,22 @@ package step
 // 在密码学中Nonce是一个只被使用一次的任意或非重复的随机数值
 
 import (
+	"context"
 	"errors"
-	"net/http"
+	"sync"
 )
 
-func GetNonce(newNonceUrl string) (string, error) {
-	resp, err := http.Head(newNonceUrl)
+// nonce 池最多缓存的数量, 超出后丢弃最旧的 nonce
+const maxPooledNonces = 16
+
+func (client *Client) GetNonce(ctx context.Context, newNonceUrl string) (string, error) {
+	resp, err := client.head(ctx, newNonceUrl)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	
+	err = checkResponse(resp, nil)
 	if err != nil {
 		return "", err
 	}
@@ -20,5 +30,59 @@ func GetNonce(newNonceUrl string) (string, error) {
 	}
 	
 	replayNonce := resp.Header.Get("Replay-Nonce")
+	if replayNonce == "" {
+		return "", errors.New("response missing Replay-Nonce header")
+	}
+	
 	return replayNonce, nil
 }
+
+// noncePool 收集每个响应中的 Replay-Nonce, 签名时优先使用池中的 nonce
+// https://datatracker.ietf.org/doc/html/rfc8555#section-6.5
+
+type noncePool struct {
+	mu     sync.Mutex
+	nonces []string
+}
+
+func (pool *noncePool) Push(nonce string) {
+	if nonce == "" {
+		return
+	}
+	
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	
+	pool.nonces = append(pool.nonces, nonce)
+	if len(pool.nonces) > maxPooledNonces {
+		pool.nonces = pool.nonces[len(pool.nonces)-maxPooledNonces:]
+	}
+}
+
+func (pool *noncePool) Pop() (string, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	
+	if len(pool.nonces) == 0 {
+		return "", false
+	}
+	
+	nonce := pool.nonces[len(pool.nonces)-1]
+	pool.nonces = pool.nonces[:len(pool.nonces)-1]
+	return nonce, true
+}
+
+// 池为空时才通过 newNonce 获取新的 nonce
+
+func (client *Client) nonce(ctx context.Context) (string, error) {
+	if nonce, ok := client.nonces.Pop(); ok {
+		return nonce, nil
+	}
+	
+	directory, err := client.Directory(ctx)
+	if err != nil {
+		return "", err
+	}
+	
+	return client.GetNonce(ctx, directory.NewNonce)
+}
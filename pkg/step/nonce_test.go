@@ -0,0 +1,91 @@
+package step
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNoncePool(t *testing.T) {
+	var pool noncePool
+	
+	_, ok := pool.Pop()
+	require.False(t, ok)
+	
+	pool.Push("")
+	pool.Push("a")
+	pool.Push("b")
+	
+	nonce, ok := pool.Pop()
+	require.True(t, ok)
+	require.Equal(t, "b", nonce)
+	
+	for i := 0; i < maxPooledNonces+5; i++ {
+		pool.Push(fmt.Sprintf("n%d", i))
+	}
+	require.Len(t, pool.nonces, maxPooledNonces)
+}
+
+func TestClientBadNonceRetry(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	
+	var newNonceCount, orderCount int32
+	usedNonces := make(chan string, 4)
+	
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"newNonce":"%[1]s/nonce","newOrder":"%[1]s/order"}`, server.URL)
+	})
+	
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&newNonceCount, 1)
+		w.Header().Set("Replay-Nonce", "fresh")
+	})
+	
+	mux.HandleFunc("/order", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		jws, err := jose.ParseSigned(string(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		usedNonces <- jws.Signatures[0].Protected.Nonce
+		
+		if atomic.AddInt32(&orderCount, 1) == 1 {
+			w.Header().Set("Replay-Nonce", "retry")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(Problem{Type: ErrorBadNonce, Status: http.StatusBadRequest})
+			return
+		}
+		
+		w.Header().Set("Replay-Nonce", "next")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"status":"pending"}`)
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "")
+	order, _, err := client.NewOrder(context.Background(), server.URL+"/order", `{}`, AccountKey{Kid: "kid", PrivateKey: privateKey})
+	require.NoError(t, err)
+	require.Equal(t, "pending", order.Status)
+	
+	require.Equal(t, "fresh", <-usedNonces)
+	require.Equal(t, "retry", <-usedNonces)
+	require.EqualValues(t, 1, newNonceCount)
+	
+	nonce, ok := client.nonces.Pop()
+	require.True(t, ok)
+	require.Equal(t, "next", nonce)
+}
@@ -1,11 +1,10 @@
 package step
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"io"
-	"net/http"
+	"time"
 )
 
 // 4 新建订单
@@ -16,18 +15,44 @@ type NewOrderRequestPayload struct {
 	Identifiers []Identifier `json:"identifiers"`         // required, array of object
 	NotBefore   string       `json:"notBefore,omitempty"` // optional, e.g: "2016-01-01T00:00:00Z"
 	NotAfter    string       `json:"notAfter,omitempty"`  // optional, e.g: "2016-01-08T00:00:00Z"
+	Replaces    string       `json:"replaces,omitempty"`  // optional, ARI 被替换证书的标识 (CertificateID)
+	Profile     string       `json:"profile,omitempty"`   // optional, 证书配置, 必须为 directory meta profiles 中的名称
 }
 
-func GenerateNewOrderPayload(identifiers []Identifier) (string, error) {
+// NewOrderOptions 新建订单的可选参数
+
+type NewOrderOptions struct {
+	Replaces  string    // 续期时被替换证书的 ARI 标识, CA 不支持 ARI 时必须为空
+	Profile   string    // 证书配置名称, 为空时由 CA 选择默认配置
+	NotBefore time.Time // 请求的证书有效期, 零值表示不指定, 部分 CA (如 Let's Encrypt) 不支持
+	NotAfter  time.Time
+}
+
+func GenerateNewOrderPayload(identifiers []Identifier, options NewOrderOptions) (string, error) {
 	if len(identifiers) == 0 {
 		return "", errors.New("identifiers 不能为空")
 	}
 	
-	// NotBefore and NotAfter are not supported
+	if !options.NotBefore.IsZero() && !options.NotAfter.IsZero() && !options.NotAfter.After(options.NotBefore) {
+		return "", errors.New("notAfter 必须晚于 notBefore")
+	}
+	
+	if !options.NotAfter.IsZero() && !options.NotAfter.After(time.Now()) {
+		return "", errors.New("notAfter 必须晚于当前时间")
+	}
+	
 	payload := NewOrderRequestPayload{
 		Identifiers: identifiers,
-		//NotBefore:   time.Now().Format("2006-01-02T15:04:00+04:00"),
-		//NotAfter:    time.Now().AddDate(0, 2, 0).Format("2006-01-02T15:04:00+04:00"),
+		Replaces:    options.Replaces,
+		Profile:     options.Profile,
+	}
+	
+	if !options.NotBefore.IsZero() {
+		payload.NotBefore = options.NotBefore.UTC().Format(time.RFC3339)
+	}
+	
+	if !options.NotAfter.IsZero() {
+		payload.NotAfter = options.NotAfter.UTC().Format(time.RFC3339)
 	}
 	
 	payloadByte, err := json.Marshal(payload)
@@ -49,62 +74,37 @@ type OrderResponse struct {
 	Authorizations []string     `json:"authorizations"`        // required, 订单需要依次完成的授权验证资源（Auth-Z）的链接    不允许为空数组（必须至少有一个流程）
 	Finalize       string       `json:"finalize"`              //  required, 授权验证完成后，调用finalize接口签发证书（包括CSR也是在这一步提交的）, Once the client believes it has fulfilled the server's requirements, it should send a POST request to the order resource's finalize URL. The POST body MUST include a CSR
 	Certificate    string       `json:"certificate,omitempty"` // optional
+	Profile        string       `json:"profile,omitempty"`     // optional, CA 实际使用的证书配置
 }
 
-func NewOrder(url string, req []byte) (OrderResponse, string, string, error) {
+func (client *Client) NewOrder(ctx context.Context, url, payload string, key AccountKey) (OrderResponse, string, error) {
 	var orderResponse OrderResponse
 	
-	param := bytes.NewBuffer(req)
-	resp, err := http.Post(url, "application/jose+json", param)
+	resp, respBodyByte, err := client.postJWS(ctx, url, payload, key)
 	if err != nil {
-		return orderResponse, "", "", err
-	}
-	
-	respBody := resp.Body
-	defer resp.Body.Close()
-	
-	respBodyByte, err := io.ReadAll(respBody)
-	if err != nil {
-		return orderResponse, "", "", err
-	}
-	
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return orderResponse, "", "", errors.New(string(respBodyByte))
+		return orderResponse, "", err
 	}
 	
 	err = json.Unmarshal(respBodyByte, &orderResponse)
 	if err != nil {
-		return orderResponse, "", "", err
+		return orderResponse, "", err
 	}
 	
 	location := resp.Header.Get("Location")
-	replayNonce := resp.Header.Get("Replay-Nonce")
-	return orderResponse, location, replayNonce, nil
+	return orderResponse, location, nil
 }
 
-func GetOrder(orderUrl string, req []byte) (OrderResponse, string, error) {
+// POST-as-GET 获取订单
+
+func (client *Client) GetOrder(ctx context.Context, orderUrl string, key AccountKey) (OrderResponse, error) {
 	var orderResponse OrderResponse
-	param := bytes.NewBuffer(req)
-	resp, err := http.Post(orderUrl, "application/jose+json", param)
+	_, respBodyByte, err := client.postJWS(ctx, orderUrl, "", key)
 	if err != nil {
-		return orderResponse, "", err
-	}
-	
-	respBody := resp.Body
-	defer resp.Body.Close()
-	
-	respBodyByte, err := io.ReadAll(respBody)
-	if err != nil {
-		return orderResponse, "", err
+		return orderResponse, err
 	}
 	
 	err = json.Unmarshal(respBodyByte, &orderResponse)
-	if err != nil {
-		return orderResponse, "", err
-	}
-	
-	replayNonce := resp.Header.Get("Replay-Nonce")
-	return orderResponse, replayNonce, nil
+	return orderResponse, err
 }
 
 // FinalizeOrder
@@ -127,48 +127,51 @@ func GenerateFinalizeOrderPayload(csr string) (string, error) {
 
 // require Order's status ready
 
-func FinalizeOrder(finalizeOrderUrl string, req []byte) (OrderResponse, error) {
+func (client *Client) FinalizeOrder(ctx context.Context, finalizeOrderUrl, payload string, key AccountKey) (OrderResponse, error) {
 	var order OrderResponse
 	
-	param := bytes.NewBuffer(req)
-	resp, err := http.Post(finalizeOrderUrl, "application/jose+json", param)
+	_, respBodyByte, err := client.postJWS(ctx, finalizeOrderUrl, payload, key)
 	if err != nil {
 		return order, err
 	}
 	
-	respBody := resp.Body
-	defer resp.Body.Close()
-	
-	respBodyByte, err := io.ReadAll(respBody)
-	if err != nil {
-		return order, err
-	}
-	
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		return order, errors.New(string(respBodyByte))
-	}
-	
 	err = json.Unmarshal(respBodyByte, &order)
 	return order, err
 }
 
-//
-// Each account object includes an "orders" URL from which a list of
-// orders created by the account can be fetched via POST-as-GET request.
-// The result of the request MUST be a JSON object whose "orders" field
-// is an array of URLs, each identifying an order belonging to the account.
+// 获取账户的订单列表, 分页时响应头 Link rel="next" 为下一页地址
+// Each account object includes an "orders" URL from which a list of orders created by the account can be fetched via POST-as-GET request.
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.1.2.1
 
 type OrdersResponse struct {
-	orders []string
+	Orders []string `json:"orders"`
 }
 
-// Each account object includes an "orders" URL from which a list of orders created by the account can be fetched via POST-as-GET request.
-// Link: <https://example.com/acme/directory>;rel="index"
-//   Link: <https://example.com/acme/orders/rzGoeA?cursor=2>;rel="next"
-
-/*
-type GetOrdersResponse struct {
-	Orders []string `json:"orders,omitempty"`
+func (client *Client) ListAccountOrders(ctx context.Context, ordersUrl string, key AccountKey) ([]string, error) {
+	var orders []string
+	visited := make(map[string]bool)
+	
+	for ordersUrl != "" && !visited[ordersUrl] {
+		visited[ordersUrl] = true
+		
+		resp, respBodyByte, err := client.postJWS(ctx, ordersUrl, "", key)
+		if err != nil {
+			return orders, err
+		}
+		
+		var ordersResponse OrdersResponse
+		err = json.Unmarshal(respBodyByte, &ordersResponse)
+		if err != nil {
+			return orders, err
+		}
+		
+		orders = append(orders, ordersResponse.Orders...)
+		
+		ordersUrl = ""
+		if next := getLinks(resp.Header, "next"); len(next) > 0 {
+			ordersUrl = next[0]
+		}
+	}
+	
+	return orders, nil
 }
-
-*/
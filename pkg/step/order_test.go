@@ -0,0 +1,96 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetLinks(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://example.com/acme/directory>;rel="index"`)
+	header.Add("Link", `<https://example.com/acme/orders/1?cursor=2>;rel="next", <https://example.com/acme/cert/1/1>;rel="alternate"`)
+	
+	require.Equal(t, []string{"https://example.com/acme/orders/1?cursor=2"}, getLinks(header, "next"))
+	require.Equal(t, []string{"https://example.com/acme/cert/1/1"}, getLinks(header, "alternate"))
+	require.Empty(t, getLinks(header, "up"))
+}
+
+func TestListAccountOrders(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	server := newTestAcmeServer(t)
+	
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", "nonce-2")
+		
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orders?cursor=2>;rel="next"`, server.URL))
+			fmt.Fprintf(w, `{"orders":["%[1]s/order/1","%[1]s/order/2"]}`, server.URL)
+		case "2":
+			// 指向已访问的页面时停止
+			w.Header().Set("Link", fmt.Sprintf(`<%s/orders>;rel="next"`, server.URL))
+			fmt.Fprintf(w, `{"orders":["%[1]s/order/3"]}`, server.URL)
+		}
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	orders, err := client.ListAccountOrders(context.Background(), server.URL+"/orders",
+		AccountKey{Kid: server.URL + "/acct/1", PrivateKey: privateKey})
+	require.NoError(t, err)
+	require.Equal(t, []string{server.URL + "/order/1", server.URL + "/order/2", server.URL + "/order/3"}, orders)
+}
+
+func TestGenerateNewOrderPayload(t *testing.T) {
+	identifiers := []Identifier{{Type: "dns", Value: "example.com"}}
+	notBefore := time.Now().Add(time.Hour).Truncate(time.Second)
+	
+	payload, err := GenerateNewOrderPayload(identifiers, NewOrderOptions{
+		Profile:   "shortlived",
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(6 * 24 * time.Hour),
+	})
+	require.NoError(t, err)
+	
+	var newOrderPayload NewOrderRequestPayload
+	require.NoError(t, json.Unmarshal([]byte(payload), &newOrderPayload))
+	require.Equal(t, "shortlived", newOrderPayload.Profile)
+	require.Equal(t, notBefore.UTC().Format(time.RFC3339), newOrderPayload.NotBefore)
+	require.Equal(t, notBefore.Add(6*24*time.Hour).UTC().Format(time.RFC3339), newOrderPayload.NotAfter)
+	
+	// 未指定时不包含 profile/notBefore/notAfter
+	payload, err = GenerateNewOrderPayload(identifiers, NewOrderOptions{})
+	require.NoError(t, err)
+	require.Equal(t, `{"identifiers":[{"type":"dns","value":"example.com"}]}`, payload)
+	
+	_, err = GenerateNewOrderPayload(identifiers, NewOrderOptions{NotBefore: notBefore, NotAfter: notBefore.Add(-time.Hour)})
+	require.Error(t, err)
+	
+	_, err = GenerateNewOrderPayload(identifiers, NewOrderOptions{NotAfter: time.Now().Add(-time.Hour)})
+	require.Error(t, err)
+}
+
+func TestSupportsProfile(t *testing.T) {
+	var directory DirectoryResponse
+	err := json.Unmarshal([]byte(`{"meta":{"profiles":{"classic":"The same profile you're accustomed to","shortlived":"A short-lived cert profile"}}}`), &directory)
+	require.NoError(t, err)
+	
+	require.True(t, directory.SupportsProfile("classic"))
+	require.True(t, directory.SupportsProfile("shortlived"))
+	require.False(t, directory.SupportsProfile("tlsserver"))
+	require.False(t, DirectoryResponse{}.SupportsProfile("classic"))
+}
+
+func TestNewIdentifier(t *testing.T) {
+	require.Equal(t, Identifier{Type: IdentifierTypeDNS, Value: "www.example.com"}, NewIdentifier("www.example.com"))
+	require.Equal(t, Identifier{Type: IdentifierTypeDNS, Value: "*.example.com"}, NewIdentifier("*.example.com"))
+	require.Equal(t, Identifier{Type: IdentifierTypeIP, Value: "192.0.2.1"}, NewIdentifier("192.0.2.1"))
+	require.Equal(t, Identifier{Type: IdentifierTypeIP, Value: "2001:db8::1"}, NewIdentifier("2001:0db8:0:0:0:0:0:1"))
+	require.True(t, NewIdentifier("192.0.2.1").IsIP())
+}
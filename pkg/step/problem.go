@@ -0,0 +1,148 @@
+package step
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimited 未返回 Retry-After 时的默认等待时间 (每小时 5 次验证失败限制)
+const defaultRateLimitRetryAfter = time.Hour
+
+// ACME 错误类型
+// https://datatracker.ietf.org/doc/html/rfc8555#section-6.7
+
+const (
+	acmeErrorPrefix = "urn:ietf:params:acme:error:"
+	
+	ErrorBadNonce                = acmeErrorPrefix + "badNonce"
+	ErrorRateLimited             = acmeErrorPrefix + "rateLimited"
+	ErrorUserActionRequired      = acmeErrorPrefix + "userActionRequired"
+	ErrorUnauthorized            = acmeErrorPrefix + "unauthorized"
+	ErrorMalformed               = acmeErrorPrefix + "malformed"
+	ErrorAccountDoesNotExist     = acmeErrorPrefix + "accountDoesNotExist"
+	ErrorExternalAccountRequired = acmeErrorPrefix + "externalAccountRequired"
+	ErrorOrderNotReady           = acmeErrorPrefix + "orderNotReady"
+	ErrorAlreadyRevoked          = acmeErrorPrefix + "alreadyRevoked"
+)
+
+// Problem RFC 7807 problem document (application/problem+json)
+// https://datatracker.ietf.org/doc/html/rfc8555#section-6.7.1
+
+type Problem struct {
+	Type        string      `json:"type,omitempty"`
+	Detail      string      `json:"detail,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Instance    string      `json:"instance,omitempty"`    // userActionRequired 时为需要用户访问的 url
+	Identifier  *Identifier `json:"identifier,omitempty"`  // 仅 subproblems 中存在
+	Subproblems []Problem   `json:"subproblems,omitempty"` // 多个标识符各自的错误
+	
+	RetryAfter time.Duration `json:"-"` // 响应头 Retry-After, 429/503 时 CA 要求的等待时间
+}
+
+func (problem *Problem) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "acme: error: %d :: %s :: %s", problem.Status, problem.Type, problem.Detail)
+	
+	if problem.Instance != "" {
+		fmt.Fprintf(&b, " :: %s", problem.Instance)
+	}
+	
+	for _, sub := range problem.Subproblems {
+		fmt.Fprintf(&b, ", problem: %q", sub.Type)
+		if sub.Identifier != nil {
+			fmt.Fprintf(&b, " :: %s %s", sub.Identifier.Type, sub.Identifier.Value)
+		}
+		fmt.Fprintf(&b, " :: %s", sub.Detail)
+	}
+	
+	return b.String()
+}
+
+// 非 2xx/3xx 响应解析为 Problem, 无法解析时将响应内容作为 Detail
+
+func checkResponse(resp *http.Response, respBodyByte []byte) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	
+	problem := &Problem{}
+	err := json.Unmarshal(respBodyByte, problem)
+	if err != nil || problem.Type == "" {
+		problem = &Problem{Detail: string(respBodyByte)}
+	}
+	
+	if problem.Status == 0 {
+		problem.Status = resp.StatusCode
+	}
+	
+	problem.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	return problem
+}
+
+// Retry-After 可以是秒数或 HTTP-date
+// https://datatracker.ietf.org/doc/html/rfc7231#section-7.1.3
+
+func parseRetryAfter(retryAfter string, now time.Time) time.Duration {
+	if retryAfter == "" {
+		return 0
+	}
+	
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	
+	date, err := http.ParseTime(retryAfter)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+	
+	return date.Sub(now)
+}
+
+// RetryAfter 返回 CA 要求的等待时间, rateLimited 未携带 Retry-After 时使用默认等待时间
+
+func RetryAfter(err error) (time.Duration, bool) {
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		return 0, false
+	}
+	
+	if problem.RetryAfter > 0 {
+		return problem.RetryAfter, true
+	}
+	
+	if problem.Type == ErrorRateLimited {
+		return defaultRateLimitRetryAfter, true
+	}
+	
+	return 0, false
+}
+
+func IsProblemType(err error, problemType string) bool {
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		return false
+	}
+	
+	return problem.Type == problemType
+}
+
+func IsBadNonce(err error) bool {
+	return IsProblemType(err, ErrorBadNonce)
+}
+
+func IsRateLimited(err error) bool {
+	return IsProblemType(err, ErrorRateLimited)
+}
+
+func IsUserActionRequired(err error) bool {
+	return IsProblemType(err, ErrorUserActionRequired)
+}
@@ -0,0 +1,72 @@
+package step
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCheckResponse(t *testing.T) {
+	body := `{
+		"type": "urn:ietf:params:acme:error:malformed",
+		"detail": "Some of the identifiers requested were rejected",
+		"subproblems": [
+			{
+				"type": "urn:ietf:params:acme:error:rejectedIdentifier",
+				"detail": "This CA will not issue for \"example.net\"",
+				"identifier": {"type": "dns", "value": "example.net"}
+			}
+		]
+	}`
+	
+	err := checkResponse(&http.Response{StatusCode: 403}, []byte(body))
+	require.Error(t, err)
+	
+	problem, ok := err.(*Problem)
+	require.True(t, ok)
+	require.Equal(t, ErrorMalformed, problem.Type)
+	require.Equal(t, 403, problem.Status)
+	require.Len(t, problem.Subproblems, 1)
+	require.Equal(t, "example.net", problem.Subproblems[0].Identifier.Value)
+	require.Equal(t, `acme: error: 403 :: urn:ietf:params:acme:error:malformed :: Some of the identifiers requested were rejected, `+
+		`problem: "urn:ietf:params:acme:error:rejectedIdentifier" :: dns example.net :: This CA will not issue for "example.net"`, problem.Error())
+	
+	err = checkResponse(&http.Response{StatusCode: 200}, []byte(body))
+	require.NoError(t, err)
+	
+	err = checkResponse(&http.Response{StatusCode: 502}, []byte("Bad Gateway"))
+	require.Error(t, err)
+	require.Equal(t, "Bad Gateway", err.(*Problem).Detail)
+}
+
+func TestIsProblemType(t *testing.T) {
+	err := fmt.Errorf("创建订单失败: %w", &Problem{Type: ErrorRateLimited, Status: 429})
+	
+	require.True(t, IsRateLimited(err))
+	require.False(t, IsBadNonce(err))
+	require.False(t, IsUserActionRequired(err))
+	require.False(t, IsRateLimited(fmt.Errorf("rateLimited")))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2023, 12, 8, 14, 0, 0, 0, time.UTC)
+	require.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	require.Equal(t, time.Hour, parseRetryAfter("Fri, 08 Dec 2023 15:00:00 GMT", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("Fri, 08 Dec 2023 13:00:00 GMT", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	
+	resp := &http.Response{StatusCode: 503, Header: http.Header{"Retry-After": []string{"30"}}}
+	err := checkResponse(resp, []byte(`{"type":"urn:ietf:params:acme:error:serverInternal"}`))
+	retryAfter, ok := RetryAfter(err)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, retryAfter)
+	
+	retryAfter, ok = RetryAfter(&Problem{Type: ErrorRateLimited, Status: 429})
+	require.True(t, ok)
+	require.Equal(t, defaultRateLimitRetryAfter, retryAfter)
+	
+	_, ok = RetryAfter(&Problem{Type: ErrorMalformed, Status: 400})
+	require.False(t, ok)
+}
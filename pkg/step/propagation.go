@@ -0,0 +1,273 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"time"
+)
+
+// TXT 记录传播检查
+// 通过递归服务器跟随 CNAME, 沿 SOA 查找 zone, 再向 zone 的全部权威服务器查询, 所有权威服务器均返回期望值时检查通过
+
+// 最多跟随的 CNAME 次数
+const maxCNAMEHops = 10
+
+type PropagationOptions struct {
+	Nameservers []string      // 递归服务器, 用于查找 CNAME/SOA/NS, 为空时使用系统配置
+	Timeout     time.Duration // 等待传播的最长时间, 为 0 时只检查一次
+	Interval    time.Duration // 轮询间隔, 为 0 时使用 defaultPropagationInterval
+	Port        string        // 权威服务器端口, 为空时使用 defaultAuthoritativePort
+}
+
+const defaultPropagationInterval = 5 * time.Second
+
+const defaultAuthoritativePort = "53"
+
+// 单个权威服务器的检查结果
+
+type NameserverResult struct {
+	Nameserver string   `json:"nameserver"`
+	Values     []string `json:"values"` // 查询到的 TXT 值
+	Result     bool     `json:"result"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type PropagationReport struct {
+	FQDN         string             `json:"fqdn"`
+	ResolvedFQDN string             `json:"resolvedFqdn"` // 跟随 CNAME 后实际查询的名称
+	Zone         string             `json:"zone"`
+	Values       []string           `json:"values"` // 期望的 TXT 值
+	Nameservers  []NameserverResult `json:"nameservers"`
+	Result       bool               `json:"result"`
+}
+
+// 检查 fqdn 的 TXT 记录是否已在所有权威服务器生效, 需要同时包含 values 中的每个值
+
+func CheckTxtPropagation(ctx context.Context, fqdn string, values []string, options PropagationOptions) (PropagationReport, error) {
+	report := PropagationReport{
+		FQDN:   dns.Fqdn(fqdn),
+		Values: values,
+	}
+	
+	recursive := options.Nameservers
+	if len(recursive) == 0 {
+		recursive = recursiveNameservers
+	}
+	recursive = ParseNameservers(recursive)
+	
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultPropagationInterval
+	}
+	
+	port := options.Port
+	if port == "" {
+		port = defaultAuthoritativePort
+	}
+	
+	// 1. 跟随 CNAME
+	resolvedFqdn, err := followCNAME(ctx, report.FQDN, recursive)
+	if err != nil {
+		return report, err
+	}
+	report.ResolvedFQDN = resolvedFqdn
+	
+	// 2. 查找 zone 和权威服务器
+	zone, err := findZoneByFqdn(ctx, resolvedFqdn, recursive)
+	if err != nil {
+		return report, err
+	}
+	report.Zone = zone
+	
+	authoritative, err := lookupAuthoritativeNameservers(ctx, zone, recursive, port)
+	if err != nil {
+		return report, err
+	}
+	
+	// 3. 轮询全部权威服务器
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		report.Nameservers = nil
+		report.Result = true
+		for _, ns := range authoritative {
+			result := checkAuthoritativeTxt(ctx, resolvedFqdn, values, ns)
+			report.Nameservers = append(report.Nameservers, result)
+			report.Result = report.Result && result.Result
+		}
+		
+		if report.Result {
+			return report, nil
+		}
+		
+		if !time.Now().Add(interval).Before(deadline) {
+			return report, fmt.Errorf("txt record %s not propagated to all authoritative nameservers", report.FQDN)
+		}
+		
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// 跟随 CNAME 返回实际需要添加 TXT 记录的名称, nameservers 为空时使用系统配置
+
+func ResolveChallengeFqdn(ctx context.Context, fqdn string, nameservers []string) (string, error) {
+	if len(nameservers) == 0 {
+		nameservers = recursiveNameservers
+	}
+	
+	return followCNAME(ctx, dns.Fqdn(fqdn), ParseNameservers(nameservers))
+}
+
+// 跟随多跳 CNAME, 出现循环或超过 maxCNAMEHops 时返回错误
+
+func followCNAME(ctx context.Context, fqdn string, nameservers []string) (string, error) {
+	visited := map[string]bool{fqdn: true}
+	
+	for i := 0; i < maxCNAMEHops; i++ {
+		r, err := dnsQuery(ctx, fqdn, dns.TypeCNAME, nameservers, true)
+		if err != nil {
+			return "", err
+		}
+		
+		target := updateDomainWithCName(r, fqdn)
+		if target == fqdn {
+			return fqdn, nil
+		}
+		
+		if visited[target] {
+			return "", fmt.Errorf("cname loop detected at %s", target)
+		}
+		visited[target] = true
+		fqdn = target
+	}
+	
+	return "", fmt.Errorf("too many cname hops for %s", fqdn)
+}
+
+// 从 fqdn 开始逐级向上查询 SOA, 第一个返回 SOA 的名称即为 zone
+
+func findZoneByFqdn(ctx context.Context, fqdn string, nameservers []string) (string, error) {
+	for _, index := range dns.Split(fqdn) {
+		domain := fqdn[index:]
+		
+		r, err := dnsQuery(ctx, domain, dns.TypeSOA, nameservers, true)
+		if err != nil {
+			return "", err
+		}
+		
+		if r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError {
+			return "", fmt.Errorf("unexpected rcode %s for %s SOA", dns.RcodeToString[r.Rcode], domain)
+		}
+		
+		for _, rr := range r.Answer {
+			if soa, ok := rr.(*dns.SOA); ok && soa.Hdr.Name == domain {
+				return domain, nil
+			}
+		}
+	}
+	
+	return "", fmt.Errorf("could not find zone for %s", fqdn)
+}
+
+// 查询 zone 的 NS 记录并解析为 ip:port, 无法解析时使用 NS 域名
+
+func lookupAuthoritativeNameservers(ctx context.Context, zone string, nameservers []string, port string) ([]string, error) {
+	r, err := dnsQuery(ctx, zone, dns.TypeNS, nameservers, true)
+	if err != nil {
+		return nil, err
+	}
+	
+	var authoritative []string
+	for _, rr := range r.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok {
+			continue
+		}
+		
+		addresses := resolveHost(ctx, ns.Ns, nameservers)
+		if len(addresses) == 0 {
+			addresses = []string{strings.TrimSuffix(ns.Ns, ".")}
+		}
+		
+		for _, address := range addresses {
+			authoritative = append(authoritative, net.JoinHostPort(address, port))
+		}
+	}
+	
+	if len(authoritative) == 0 {
+		return nil, fmt.Errorf("could not find authoritative nameservers for %s", zone)
+	}
+	
+	return authoritative, nil
+}
+
+func resolveHost(ctx context.Context, host string, nameservers []string) []string {
+	var addresses []string
+	for _, rtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		r, err := dnsQuery(ctx, host, rtype, nameservers, true)
+		if err != nil {
+			continue
+		}
+		
+		for _, rr := range r.Answer {
+			switch record := rr.(type) {
+			case *dns.A:
+				addresses = append(addresses, record.A.String())
+			case *dns.AAAA:
+				addresses = append(addresses, record.AAAA.String())
+			}
+		}
+	}
+	
+	return addresses
+}
+
+// 向权威服务器发送非递归查询
+
+func checkAuthoritativeTxt(ctx context.Context, fqdn string, values []string, ns string) NameserverResult {
+	result := NameserverResult{Nameserver: ns}
+	
+	r, err := sendDNSQuery(ctx, createDNSMsg(fqdn, dns.TypeTXT, false), ns)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	
+	if r.Rcode != dns.RcodeSuccess {
+		result.Error = fmt.Sprintf("unexpected rcode %s", dns.RcodeToString[r.Rcode])
+		return result
+	}
+	
+	for _, rr := range r.Answer {
+		if txt, ok := rr.(*dns.TXT); ok && txt.Hdr.Name == fqdn {
+			result.Values = append(result.Values, strings.Join(txt.Txt, ""))
+		}
+	}
+	
+	result.Result = containsAll(result.Values, values)
+	return result
+}
+
+func containsAll(values, expected []string) bool {
+	for _, value := range expected {
+		found := false
+		for _, v := range values {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		
+		if !found {
+			return false
+		}
+	}
+	
+	return true
+}
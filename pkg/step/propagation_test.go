@@ -0,0 +1,118 @@
+package step
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 本地 DNS 服务器, 同时作为递归服务器和 example.com 的权威服务器
+
+type testDNSServer struct {
+	mu   sync.Mutex
+	txts []string
+}
+
+func (server *testDNSServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	
+	q := r.Question[0]
+	hdr := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: 60}
+	
+	switch {
+	case q.Name == "example.com." && q.Qtype == dns.TypeSOA:
+		m.Answer = append(m.Answer, &dns.SOA{Hdr: hdr, Ns: "ns1.example.com.", Mbox: "admin.example.com.", Serial: 1})
+	case q.Name == "example.com." && q.Qtype == dns.TypeNS:
+		m.Answer = append(m.Answer, &dns.NS{Hdr: hdr, Ns: "ns1.example.com."})
+	case q.Name == "ns1.example.com." && q.Qtype == dns.TypeA:
+		m.Answer = append(m.Answer, &dns.A{Hdr: hdr, A: net.ParseIP("127.0.0.1")})
+	case q.Name == "_acme-challenge.www.example.com." && q.Qtype == dns.TypeCNAME:
+		m.Answer = append(m.Answer, &dns.CNAME{Hdr: hdr, Target: "_acme-challenge.alias.example.com."})
+	case q.Name == "_acme-challenge.alias.example.com." && q.Qtype == dns.TypeCNAME:
+		m.Answer = append(m.Answer, &dns.CNAME{Hdr: hdr, Target: "_acme-challenge.target.example.com."})
+	case q.Name == "_acme-challenge.loop.example.com." && q.Qtype == dns.TypeCNAME:
+		m.Answer = append(m.Answer, &dns.CNAME{Hdr: hdr, Target: "_acme-challenge.loop2.example.com."})
+	case q.Name == "_acme-challenge.loop2.example.com." && q.Qtype == dns.TypeCNAME:
+		m.Answer = append(m.Answer, &dns.CNAME{Hdr: hdr, Target: "_acme-challenge.loop.example.com."})
+	case q.Name == "_acme-challenge.target.example.com." && q.Qtype == dns.TypeTXT:
+		server.mu.Lock()
+		for _, txt := range server.txts {
+			m.Answer = append(m.Answer, &dns.TXT{Hdr: hdr, Txt: []string{txt}})
+		}
+		server.mu.Unlock()
+	}
+	
+	w.WriteMsg(m)
+}
+
+func (server *testDNSServer) setTxts(txts ...string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.txts = txts
+}
+
+func newTestDNSServer(t *testing.T) (*testDNSServer, string, string) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	
+	handler := &testDNSServer{}
+	server := &dns.Server{PacketConn: pc, Handler: handler}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	
+	_, port, err := net.SplitHostPort(pc.LocalAddr().String())
+	require.NoError(t, err)
+	
+	return handler, pc.LocalAddr().String(), port
+}
+
+func TestCheckTxtPropagation(t *testing.T) {
+	server, addr, port := newTestDNSServer(t)
+	options := PropagationOptions{Nameservers: []string{addr}, Port: port}
+	
+	// 跟随两次 CNAME, 记录未生效时返回每个权威服务器的结果
+	server.setTxts("other")
+	report, err := CheckTxtPropagation(context.Background(), "_acme-challenge.www.example.com", []string{"value-1"}, options)
+	require.Error(t, err)
+	require.Equal(t, "_acme-challenge.www.example.com.", report.FQDN)
+	require.Equal(t, "_acme-challenge.target.example.com.", report.ResolvedFQDN)
+	require.Equal(t, "example.com.", report.Zone)
+	require.Len(t, report.Nameservers, 1)
+	require.Equal(t, addr, report.Nameservers[0].Nameserver)
+	require.Equal(t, []string{"other"}, report.Nameservers[0].Values)
+	require.False(t, report.Result)
+	
+	// 轮询期间记录生效
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.setTxts("value-1", "value-2")
+	}()
+	
+	options.Timeout = 2 * time.Second
+	options.Interval = 20 * time.Millisecond
+	report, err = CheckTxtPropagation(context.Background(), "_acme-challenge.www.example.com", []string{"value-1", "value-2"}, options)
+	require.NoError(t, err)
+	require.True(t, report.Result)
+	require.True(t, report.Nameservers[0].Result)
+	
+	// CNAME 循环
+	_, err = CheckTxtPropagation(context.Background(), "_acme-challenge.loop.example.com", []string{"value-1"}, options)
+	require.Error(t, err)
+}
+
+func TestResolveChallengeFqdn(t *testing.T) {
+	_, addr, _ := newTestDNSServer(t)
+	
+	fqdn, err := ResolveChallengeFqdn(context.Background(), "_acme-challenge.www.example.com", []string{addr})
+	require.NoError(t, err)
+	require.Equal(t, "_acme-challenge.target.example.com.", fqdn)
+	
+	fqdn, err = ResolveChallengeFqdn(context.Background(), "_acme-challenge.example.com", []string{addr})
+	require.NoError(t, err)
+	require.Equal(t, "_acme-challenge.example.com.", fqdn)
+}
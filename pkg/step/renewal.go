@@ -0,0 +1,112 @@
+package step
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ACME Renewal Information (ARI)
+// https://datatracker.ietf.org/doc/html/rfc9773
+
+// 未返回 Retry-After 时查询续期信息的间隔
+const defaultRenewalInfoRetryAfter = 6 * time.Hour
+
+type RenewalInfo struct {
+	SuggestedWindow RenewalWindow `json:"suggestedWindow"`          // required, 建议续期的时间窗口
+	ExplanationURL  string        `json:"explanationURL,omitempty"` // optional, 窗口提前 (如批量撤销) 时的说明
+	
+	RetryAfter time.Duration `json:"-"` // 下次查询续期信息的时间
+}
+
+type RenewalWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// 在建议窗口内随机选择续期时间, 避免大量客户端同时续期
+
+func (renewalInfo RenewalInfo) RandomTime() time.Time {
+	start, end := renewalInfo.SuggestedWindow.Start, renewalInfo.SuggestedWindow.End
+	if !end.After(start) {
+		return start
+	}
+	
+	return start.Add(time.Duration(rand.Int63n(int64(end.Sub(start)))))
+}
+
+// 证书标识: base64url(AKI keyIdentifier) "." base64url(serial DER)
+// https://datatracker.ietf.org/doc/html/rfc9773#section-4.1
+
+func CertificateID(cert *x509.Certificate) (string, error) {
+	if len(cert.AuthorityKeyId) == 0 {
+		return "", errors.New("certificate missing authority key identifier")
+	}
+	
+	if cert.SerialNumber == nil || cert.SerialNumber.Sign() <= 0 {
+		return "", errors.New("certificate has invalid serial number")
+	}
+	
+	// DER 编码的 INTEGER 为有符号数, 最高位为 1 时需要补 0
+	serial := cert.SerialNumber.Bytes()
+	if serial[0]&0x80 != 0 {
+		serial = append([]byte{0}, serial...)
+	}
+	
+	return base64.RawURLEncoding.EncodeToString(cert.AuthorityKeyId) + "." + base64.RawURLEncoding.EncodeToString(serial), nil
+}
+
+// 获取证书续期信息, 为不需要签名的 GET 请求
+
+func (client *Client) GetRenewalInfo(ctx context.Context, renewalInfoUrl, certID string) (RenewalInfo, error) {
+	var renewalInfo RenewalInfo
+	
+	resp, err := client.get(ctx, strings.TrimSuffix(renewalInfoUrl, "/")+"/"+certID)
+	if err != nil {
+		return renewalInfo, err
+	}
+	defer resp.Body.Close()
+	
+	respBodyByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return renewalInfo, err
+	}
+	
+	err = checkResponse(resp, respBodyByte)
+	if err != nil {
+		return renewalInfo, err
+	}
+	
+	err = json.Unmarshal(respBodyByte, &renewalInfo)
+	if err != nil {
+		return renewalInfo, err
+	}
+	
+	renewalInfo.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if renewalInfo.RetryAfter <= 0 {
+		renewalInfo.RetryAfter = defaultRenewalInfoRetryAfter
+	}
+	
+	return renewalInfo, nil
+}
+
+// CA 不支持 ARI 时, 使用证书有效期的最后三分之一开始的一段时间作为续期窗口
+
+func DefaultRenewalInfo(cert *x509.Certificate) RenewalInfo {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	start := cert.NotBefore.Add(lifetime * 2 / 3)
+	
+	return RenewalInfo{
+		SuggestedWindow: RenewalWindow{
+			Start: start,
+			End:   start.Add(lifetime / 9),
+		},
+		RetryAfter: defaultRenewalInfoRetryAfter,
+	}
+}
@@ -0,0 +1,72 @@
+package step
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCertificateID(t *testing.T) {
+	// https://datatracker.ietf.org/doc/html/rfc9773#section-4.1 示例
+	aki, _ := hex.DecodeString("69885B6B87464041E1B37B847BA0AE2CDE01C8D4")
+	serial, _ := new(big.Int).SetString("0087654321", 16)
+	
+	certID, err := CertificateID(&x509.Certificate{AuthorityKeyId: aki, SerialNumber: serial})
+	require.NoError(t, err)
+	require.Equal(t, "aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE", certID)
+	
+	_, err = CertificateID(&x509.Certificate{SerialNumber: serial})
+	require.Error(t, err, "缺少 AKI 时无法计算证书标识")
+}
+
+func TestGetRenewalInfo(t *testing.T) {
+	server := newTestAcmeServer(t)
+	
+	retryAfter := "3600"
+	server.Config.Handler.(*http.ServeMux).HandleFunc("/renewal-info/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/renewal-info/aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE", r.URL.Path)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		fmt.Fprint(w, `{"suggestedWindow":{"start":"2025-01-02T04:00:00Z","end":"2025-01-03T04:00:00Z"},"explanationURL":"https://acme.example.com/docs/ari"}`)
+	})
+	
+	client := NewClient(server.URL+"/directory", server.Client(), "auto-cert-test")
+	renewalInfo, err := client.GetRenewalInfo(context.Background(), server.URL+"/renewal-info", "aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE")
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, renewalInfo.RetryAfter)
+	require.Equal(t, "https://acme.example.com/docs/ari", renewalInfo.ExplanationURL)
+	
+	for i := 0; i < 10; i++ {
+		renewalAt := renewalInfo.RandomTime()
+		require.False(t, renewalAt.Before(renewalInfo.SuggestedWindow.Start))
+		require.True(t, renewalAt.Before(renewalInfo.SuggestedWindow.End))
+	}
+	
+	// Retry-After 为 HTTP-date
+	retryAfter = time.Now().Add(2 * time.Hour).UTC().Format(http.TimeFormat)
+	renewalInfo, err = client.GetRenewalInfo(context.Background(), server.URL+"/renewal-info", "aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE")
+	require.NoError(t, err)
+	require.InDelta(t, 2*time.Hour, renewalInfo.RetryAfter, float64(2*time.Second))
+	
+	// 未返回 Retry-After 时使用默认值
+	retryAfter = ""
+	renewalInfo, err = client.GetRenewalInfo(context.Background(), server.URL+"/renewal-info", "aYhba4dGQEHhs3uEe6CuLN4ByNQ.AIdlQyE")
+	require.NoError(t, err)
+	require.Equal(t, defaultRenewalInfoRetryAfter, renewalInfo.RetryAfter)
+}
+
+func TestDefaultRenewalInfo(t *testing.T) {
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	renewalInfo := DefaultRenewalInfo(&x509.Certificate{NotBefore: notBefore, NotAfter: notBefore.Add(90 * 24 * time.Hour)})
+	
+	require.Equal(t, notBefore.Add(60*24*time.Hour), renewalInfo.SuggestedWindow.Start)
+	require.Equal(t, notBefore.Add(70*24*time.Hour), renewalInfo.SuggestedWindow.End)
+}
@@ -0,0 +1,193 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"time"
+)
+
+// RFC 2136 动态更新 (BIND/PowerDNS/Knot 等), 使用 TSIG 签名 UPDATE 请求
+// https://datatracker.ietf.org/doc/html/rfc2136
+// https://datatracker.ietf.org/doc/html/rfc8945
+
+const (
+	defaultRfc2136TTL       = 120
+	defaultRfc2136TsigFudge = 300
+)
+
+var rfc2136TsigAlgorithms = map[string]string{
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+// 按域名后缀指定 zone 和主服务器, Zone 为空时通过 SOA 查找, Server 为空时使用 Rfc2136Config.Server
+
+type Rfc2136Zone struct {
+	Domain string
+	Zone   string
+	Server string
+}
+
+type Rfc2136Config struct {
+	Server              string // 默认主服务器, host:port, 未指定端口时使用 53
+	TsigKey             string // TSIG 密钥名称, 为空时不签名
+	TsigSecret          string // base64 编码, 设置 TsigKey 时必填
+	TsigAlgorithm       string // hmac-sha256/hmac-sha512, 设置 TsigKey 时必填
+	TTL                 uint32 // 为 0 时使用 defaultRfc2136TTL
+	Zones               []Rfc2136Zone
+	Nameservers         []string // 递归服务器, 用于查找 zone
+	PropagationTimeout  time.Duration
+	PropagationInterval time.Duration
+}
+
+type Rfc2136Provider struct {
+	config        Rfc2136Config
+	tsigKey       string
+	tsigAlgorithm string
+}
+
+func NewRfc2136Provider(config Rfc2136Config) (*Rfc2136Provider, error) {
+	provider := &Rfc2136Provider{config: config}
+	
+	if config.TTL == 0 {
+		provider.config.TTL = defaultRfc2136TTL
+	}
+	
+	if config.Server == "" {
+		for _, zone := range config.Zones {
+			if zone.Server == "" {
+				return nil, fmt.Errorf("rfc2136: server is required for %s", zone.Domain)
+			}
+		}
+		
+		if len(config.Zones) == 0 {
+			return nil, fmt.Errorf("rfc2136: server is required")
+		}
+	}
+	
+	// 只配置部分 TSIG 参数时报错, 避免发送未签名的 UPDATE
+	if config.TsigKey == "" && (config.TsigSecret != "" || config.TsigAlgorithm != "") {
+		return nil, fmt.Errorf("rfc2136: tsig key is required")
+	}
+	
+	if config.TsigKey != "" {
+		if config.TsigSecret == "" {
+			return nil, fmt.Errorf("rfc2136: tsig secret is required")
+		}
+		
+		if config.TsigAlgorithm == "" {
+			return nil, fmt.Errorf("rfc2136: tsig algorithm is required")
+		}
+		
+		algorithm, ok := rfc2136TsigAlgorithms[strings.ToLower(config.TsigAlgorithm)]
+		if !ok {
+			return nil, fmt.Errorf("rfc2136: unsupported tsig algorithm %s", config.TsigAlgorithm)
+		}
+		
+		provider.tsigKey = dns.CanonicalName(config.TsigKey)
+		provider.tsigAlgorithm = algorithm
+	}
+	
+	return provider, nil
+}
+
+func (provider *Rfc2136Provider) Present(ctx context.Context, fqdn, value string) error {
+	return provider.update(ctx, fqdn, value, true)
+}
+
+func (provider *Rfc2136Provider) CleanUp(ctx context.Context, fqdn, value string) error {
+	return provider.update(ctx, fqdn, value, false)
+}
+
+func (provider *Rfc2136Provider) Timeout() (time.Duration, time.Duration) {
+	return provider.config.PropagationTimeout, provider.config.PropagationInterval
+}
+
+// 添加或删除单条 TXT 记录, 服务器会忽略已存在的记录, 删除时只删除 value 对应的记录
+
+func (provider *Rfc2136Provider) update(ctx context.Context, fqdn, value string, insert bool) error {
+	fqdn = dns.CanonicalName(fqdn)
+	
+	zone, server, err := provider.findZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	
+	rr := &dns.TXT{
+		Hdr: dns.RR_Header{Name: fqdn, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: provider.config.TTL},
+		Txt: []string{value},
+	}
+	
+	m := new(dns.Msg)
+	m.SetUpdate(zone)
+	if insert {
+		m.Insert([]dns.RR{rr})
+	} else {
+		m.Remove([]dns.RR{rr})
+	}
+	
+	client := &dns.Client{Net: "udp", Timeout: dnsTimeout}
+	if provider.tsigKey != "" {
+		m.SetTsig(provider.tsigKey, provider.tsigAlgorithm, defaultRfc2136TsigFudge, time.Now().Unix())
+		client.TsigSecret = map[string]string{provider.tsigKey: provider.config.TsigSecret}
+	}
+	
+	reply, _, err := client.ExchangeContext(ctx, m, server)
+	if err != nil {
+		return fmt.Errorf("rfc2136: update %s in zone %s: %w", fqdn, zone, err)
+	}
+	
+	if reply.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("rfc2136: update %s in zone %s: unexpected rcode %s", fqdn, zone, dns.RcodeToString[reply.Rcode])
+	}
+	
+	return nil
+}
+
+// 按域名后缀最长匹配 zone 配置, 未配置 zone 时沿 SOA 查找
+
+func (provider *Rfc2136Provider) findZone(ctx context.Context, fqdn string) (string, string, error) {
+	var matched Rfc2136Zone
+	for _, zone := range provider.config.Zones {
+		domain := dns.CanonicalName(zone.Domain)
+		if !dns.IsSubDomain(domain, fqdn) {
+			continue
+		}
+		
+		if len(domain) > len(dns.CanonicalName(matched.Domain)) {
+			matched = zone
+		}
+	}
+	
+	server := matched.Server
+	if server == "" {
+		server = provider.config.Server
+	}
+	
+	if server == "" {
+		return "", "", fmt.Errorf("rfc2136: no server configured for %s", fqdn)
+	}
+	
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	
+	if matched.Zone != "" {
+		return dns.CanonicalName(matched.Zone), server, nil
+	}
+	
+	nameservers := provider.config.Nameservers
+	if len(nameservers) == 0 {
+		nameservers = recursiveNameservers
+	}
+	
+	zone, err := findZoneByFqdn(ctx, fqdn, ParseNameservers(nameservers))
+	if err != nil {
+		return "", "", fmt.Errorf("rfc2136: %w", err)
+	}
+	
+	return zone, server, nil
+}
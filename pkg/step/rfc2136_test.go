@@ -0,0 +1,168 @@
+package step
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTsigSecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1vbmx5LTAxMjM0NTY3ODk="
+
+// 模拟支持 RFC 2136 UPDATE 的主服务器, 只接受 TSIG 签名正确的请求
+
+type testRfc2136Server struct {
+	mu      sync.Mutex
+	zone    string
+	records map[string][]string // fqdn -> TXT
+}
+
+func (server *testRfc2136Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	
+	tsig := r.IsTsig()
+	if tsig == nil || w.TsigStatus() != nil {
+		m.Rcode = dns.RcodeNotAuth
+		w.WriteMsg(m)
+		return
+	}
+	m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+	
+	if r.Opcode != dns.OpcodeUpdate || r.Question[0].Name != server.zone {
+		m.Rcode = dns.RcodeNotZone
+		w.WriteMsg(m)
+		return
+	}
+	
+	server.mu.Lock()
+	for _, rr := range r.Ns {
+		txt := rr.(*dns.TXT)
+		name, value := txt.Hdr.Name, txt.Txt[0]
+		
+		var values []string
+		for _, v := range server.records[name] {
+			if v != value {
+				values = append(values, v)
+			}
+		}
+		
+		if txt.Hdr.Class == dns.ClassINET {
+			values = append(values, value)
+		}
+		server.records[name] = values
+	}
+	server.mu.Unlock()
+	
+	w.WriteMsg(m)
+}
+
+func (server *testRfc2136Server) txts(fqdn string) []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	
+	return server.records[fqdn]
+}
+
+func newTestRfc2136Server(t *testing.T) (*testRfc2136Server, string) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	
+	handler := &testRfc2136Server{zone: "example.com.", records: make(map[string][]string)}
+	server := &dns.Server{
+		PacketConn: pc,
+		Handler:    handler,
+		TsigSecret: map[string]string{"acme-key.": testTsigSecret},
+		// 默认只接受 QUERY
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept
+		},
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	
+	return handler, pc.LocalAddr().String()
+}
+
+func TestRfc2136Provider(t *testing.T) {
+	for _, algorithm := range []string{"hmac-sha256", "hmac-sha512"} {
+		server, addr := newTestRfc2136Server(t)
+		
+		provider, err := NewRfc2136Provider(Rfc2136Config{
+			TsigKey:       "acme-key",
+			TsigSecret:    testTsigSecret,
+			TsigAlgorithm: algorithm,
+			Zones: []Rfc2136Zone{
+				{Domain: "example.com", Zone: "example.com", Server: addr},
+			},
+		})
+		require.NoError(t, err)
+		
+		// 同一 FQDN 添加两个值, 删除时只删除对应的值
+		ctx := context.Background()
+		fqdn := "_acme-challenge.www.example.com."
+		require.NoError(t, provider.Present(ctx, fqdn, "value-1"))
+		require.NoError(t, provider.Present(ctx, fqdn, "value-2"))
+		require.Equal(t, []string{"value-1", "value-2"}, server.txts(fqdn))
+		
+		require.NoError(t, provider.CleanUp(ctx, fqdn, "value-1"))
+		require.Equal(t, []string{"value-2"}, server.txts(fqdn))
+		
+		// 未匹配 zone 配置且没有默认服务器
+		require.Error(t, provider.Present(ctx, "_acme-challenge.example.org.", "value-1"))
+	}
+	
+	// TSIG 密钥错误时服务器拒绝更新
+	server, addr := newTestRfc2136Server(t)
+	provider, err := NewRfc2136Provider(Rfc2136Config{
+		Server:        addr,
+		TsigKey:       "acme-key",
+		TsigSecret:    "d3Jvbmctc2VjcmV0",
+		TsigAlgorithm: "hmac-sha256",
+		Zones:         []Rfc2136Zone{{Domain: "example.com", Zone: "example.com"}},
+	})
+	require.NoError(t, err)
+	require.Error(t, provider.Present(context.Background(), "_acme-challenge.example.com.", "value-1"))
+	require.Empty(t, server.txts("_acme-challenge.example.com."))
+	
+	_, err = NewRfc2136Provider(Rfc2136Config{Server: addr, TsigKey: "acme-key", TsigSecret: testTsigSecret, TsigAlgorithm: "hmac-md5"})
+	require.Error(t, err)
+	
+	// 设置 TsigKey 时 secret 和算法均为必填
+	_, err = NewRfc2136Provider(Rfc2136Config{Server: addr, TsigKey: "acme-key", TsigAlgorithm: "hmac-sha256"})
+	require.Error(t, err)
+	
+	_, err = NewRfc2136Provider(Rfc2136Config{Server: addr, TsigKey: "acme-key", TsigSecret: testTsigSecret})
+	require.Error(t, err)
+	
+	_, err = NewRfc2136Provider(Rfc2136Config{Server: addr, TsigSecret: testTsigSecret, TsigAlgorithm: "hmac-sha256"})
+	require.Error(t, err)
+}
+
+func TestRfc2136ProviderFindZone(t *testing.T) {
+	_, addr, _ := newTestDNSServer(t)
+	
+	provider, err := NewRfc2136Provider(Rfc2136Config{
+		Server:      "192.0.2.1",
+		Nameservers: []string{addr},
+		Zones: []Rfc2136Zone{
+			{Domain: "corp.example.com", Zone: "corp.example.com", Server: "192.0.2.2:5353"},
+		},
+	})
+	require.NoError(t, err)
+	
+	// 域名后缀最长匹配
+	zone, server, err := provider.findZone(context.Background(), "_acme-challenge.www.corp.example.com.")
+	require.NoError(t, err)
+	require.Equal(t, "corp.example.com.", zone)
+	require.Equal(t, "192.0.2.2:5353", server)
+	
+	// 未配置 zone 时沿 SOA 查找, 使用默认服务器
+	zone, server, err = provider.findZone(context.Background(), "_acme-challenge.www.example.com.")
+	require.NoError(t, err)
+	require.Equal(t, "example.com.", zone)
+	require.Equal(t, "192.0.2.1:53", server)
+}
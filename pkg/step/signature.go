@@ -1,19 +1,22 @@
 package step
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/miekg/dns"
 	"gopkg.in/square/go-jose.v2"
+	"net"
 	"os"
 	"strconv"
+	"strings"
 )
 
 /*
@@ -47,10 +50,11 @@ func (nonceManager *nonceManager) Nonce() (string, error) {
 
 // From lego (https://github.com/go-acme/lego)
 
-func GetSignature(url, nonce, payload, kid string, privateKey *rsa.PrivateKey) (*jose.JSONWebSignature, error) {
-	var alg jose.SignatureAlgorithm
-	
-	alg = jose.RS256
+func GetSignature(url, nonce, payload, kid string, privateKey crypto.Signer) (*jose.JSONWebSignature, error) {
+	alg, err := signatureAlgorithm(privateKey)
+	if err != nil {
+		return nil, err
+	}
 	
 	// “jwk”和“kid”字段是互斥的。服务器必须拒绝包含两者的请求。
 	protected := jose.SigningKey{
@@ -87,15 +91,99 @@ func GetSignature(url, nonce, payload, kid string, privateKey *rsa.PrivateKey) (
 	return signed, nil
 }
 
+// keyChange 内层 JWS, 使用新私钥签名并内嵌 jwk, 不包含 nonce
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.5
+
+func GetKeyChangeSignature(url, payload string, newPrivateKey crypto.Signer) (*jose.JSONWebSignature, error) {
+	alg, err := signatureAlgorithm(newPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	protected := jose.SigningKey{
+		Algorithm: alg,
+		Key:       newPrivateKey,
+	}
+	
+	options := jose.SignerOptions{
+		EmbedJWK: true,
+		ExtraHeaders: map[jose.HeaderKey]interface{}{
+			"url": url,
+		},
+	}
+	
+	signer, err := jose.NewSigner(protected, &options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jose signer: %w", err)
+	}
+	
+	signed, err := signer.Sign([]byte(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign content: %w", err)
+	}
+	
+	return signed, nil
+}
+
+// External Account Binding, 使用 CA 提供的 hmac key (HS256) 对账户公钥 jwk 签名
+// https://datatracker.ietf.org/doc/html/rfc8555#section-7.3.4
+
+func GetExternalAccountBinding(url, eabKid, eabHmacKey string, accountKey crypto.Signer) (*jose.JSONWebSignature, error) {
+	hmacKey, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(eabHmacKey, "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode eab hmac key: %w", err)
+	}
+	
+	jwk, err := json.Marshal(jose.JSONWebKey{Key: accountKey.Public()})
+	if err != nil {
+		return nil, err
+	}
+	
+	protected := jose.SigningKey{
+		Algorithm: jose.HS256,
+		Key:       hmacKey,
+	}
+	
+	options := jose.SignerOptions{
+		ExtraHeaders: map[jose.HeaderKey]interface{}{
+			"kid": eabKid,
+			"url": url,
+		},
+	}
+	
+	signer, err := jose.NewSigner(protected, &options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create jose signer: %w", err)
+	}
+	
+	signed, err := signer.Sign(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign content: %w", err)
+	}
+	
+	return signed, nil
+}
+
 var (
 	tlsFeatureExtensionOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
 	ocspMustStapleFeature  = []byte{0x30, 0x03, 0x02, 0x01, 0x05}
 )
 
+// san 中的 IP 字面量写入 IPAddresses, CommonName 为 IP 时留空
+
 func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, mustStaple bool) ([]byte, error) {
-	template := x509.CertificateRequest{
-		Subject:  pkix.Name{CommonName: domain},
-		DNSNames: san,
+	template := x509.CertificateRequest{}
+	if net.ParseIP(domain) == nil {
+		template.Subject = pkix.Name{CommonName: domain}
+	}
+	
+	for _, name := range san {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+			continue
+		}
+		
+		template.DNSNames = append(template.DNSNames, name)
 	}
 	
 	if mustStaple {
@@ -108,7 +196,7 @@ func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, must
 	return x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
 }
 
-func GetKeyAuthorization(token string, key *rsa.PrivateKey) (string, error) {
+func GetKeyAuthorization(token string, key crypto.Signer) (string, error) {
 	var publicKey crypto.PublicKey
 	publicKey = key.Public()
 	
@@ -134,7 +222,7 @@ func GetRecord(domain, keyAuth string) (fqdn, value string) {
 	fqdn = fmt.Sprintf("_acme-challenge.%s.", domain)
 	
 	if ok, _ := strconv.ParseBool(os.Getenv("LEGO_EXPERIMENTAL_CNAME_SUPPORT")); ok {
-		r, err := dnsQuery(fqdn, dns.TypeCNAME, recursiveNameservers, true)
+		r, err := dnsQuery(context.Background(), fqdn, dns.TypeCNAME, recursiveNameservers, true)
 		// Check if the domain has CNAME then return that
 		if err == nil && r.Rcode == dns.RcodeSuccess {
 			fqdn = updateDomainWithCName(r, fqdn)
@@ -1,8 +1,16 @@
 package step
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"strings"
 	"testing"
 )
 
@@ -12,3 +20,74 @@ func TestBase64urlDecode(t *testing.T) {
 	assert.Nil(t, err, "s1 base64urlDecode 失败")
 	fmt.Println("Payload TestBase64urlDecode: ", string(b))
 }
+
+func TestGetSignature(t *testing.T) {
+	algs := map[KeyType]jose.SignatureAlgorithm{
+		KeyTypeRSA2048: jose.RS256,
+		KeyTypeEC256:   jose.ES256,
+		KeyTypeEC384:   jose.ES384,
+	}
+	
+	for keyType, alg := range algs {
+		privateKey, err := GeneratePrivateKey(keyType)
+		require.NoError(t, err, "生成私钥失败")
+		
+		signed, err := GetSignature("https://example.com/acme/new-order", "nonce", `{"a":1}`, "", privateKey)
+		require.NoError(t, err, "签名失败")
+		
+		jws, err := jose.ParseSigned(signed.FullSerialize())
+		require.NoError(t, err)
+		require.Equal(t, string(alg), jws.Signatures[0].Header.Algorithm)
+		require.NotNil(t, jws.Signatures[0].Header.JSONWebKey, "未设置 kid 时需要内嵌 jwk")
+		
+		payload, err := jws.Verify(privateKey.Public())
+		require.NoError(t, err, "验证签名失败")
+		require.Equal(t, `{"a":1}`, string(payload))
+		
+		keyAuth, err := GetKeyAuthorization("token", privateKey)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(keyAuth, "token."))
+	}
+	
+	_, err := GeneratePrivateKey("DSA")
+	require.Error(t, err)
+}
+
+func TestCheckAccountKey(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC384)
+	require.NoError(t, err)
+	require.NoError(t, CheckAccountKey(privateKey))
+	
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.Error(t, CheckAccountKey(ed25519Key))
+	
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+	require.Error(t, CheckAccountKey(p521Key))
+}
+
+func TestGenerateCSR(t *testing.T) {
+	privateKey, err := GeneratePrivateKey(KeyTypeEC256)
+	require.NoError(t, err)
+	
+	csrByte, err := GenerateCSR(privateKey, "www.example.com", []string{"www.example.com", "192.0.2.1", "2001:db8::1"}, false)
+	require.NoError(t, err)
+	
+	csr, err := x509.ParseCertificateRequest(csrByte)
+	require.NoError(t, err)
+	require.Equal(t, "www.example.com", csr.Subject.CommonName)
+	require.Equal(t, []string{"www.example.com"}, csr.DNSNames)
+	require.Len(t, csr.IPAddresses, 2)
+	require.Equal(t, "192.0.2.1", csr.IPAddresses[0].String())
+	require.Equal(t, "2001:db8::1", csr.IPAddresses[1].String())
+	
+	// 仅包含 IP 时 CommonName 留空
+	csrByte, err = GenerateCSR(privateKey, "192.0.2.1", []string{"192.0.2.1"}, false)
+	require.NoError(t, err)
+	
+	csr, err = x509.ParseCertificateRequest(csrByte)
+	require.NoError(t, err)
+	require.Empty(t, csr.Subject.CommonName)
+	require.Empty(t, csr.DNSNames)
+}
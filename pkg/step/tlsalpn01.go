@@ -0,0 +1,188 @@
+package step
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"github.com/miekg/dns"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tls-alpn-01 验证, CA 通过 443 端口以 ALPN acme-tls/1 建立 TLS 连接, 检查自签名证书中的 acmeIdentifier 扩展
+// https://datatracker.ietf.org/doc/html/rfc8737
+
+const AcmeTlsAlpnProtocol = "acme-tls/1"
+
+// id-pe-acmeIdentifier
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// 生成 tls-alpn-01 验证证书, SAN 为验证的标识, acmeIdentifier 扩展为 key authorization 的 SHA-256 摘要
+
+func TlsAlpn01Certificate(identifier Identifier, keyAuthorization string) (tls.Certificate, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	
+	digest := sha256.Sum256([]byte(keyAuthorization))
+	extValue, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "auto-cert tls-alpn-01"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: idPeAcmeIdentifier, Critical: true, Value: extValue},
+		},
+	}
+	
+	if identifier.IsIP() {
+		template.IPAddresses = []net.IP{net.ParseIP(identifier.Value)}
+	} else {
+		template.DNSNames = []string{identifier.Value}
+	}
+	
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, privateKey.Public(), privateKey)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	
+	return tls.Certificate{
+		Certificate: [][]byte{certDER},
+		PrivateKey:  privateKey,
+	}, nil
+}
+
+// CA 验证时使用的 SNI, ip 标识使用反向解析域名
+// https://datatracker.ietf.org/doc/html/rfc8738#section-6
+
+func TlsAlpn01ServerName(identifier Identifier) (string, error) {
+	if !identifier.IsIP() {
+		return strings.ToLower(identifier.Value), nil
+	}
+	
+	reverseAddr, err := dns.ReverseAddr(identifier.Value)
+	if err != nil {
+		return "", err
+	}
+	
+	return strings.TrimSuffix(reverseAddr, "."), nil
+}
+
+// TlsAlpn01Solver 按 SNI 保存验证证书, 只响应协商 acme-tls/1 的 TLS 握手
+
+type TlsAlpn01Solver struct {
+	mu           sync.RWMutex
+	certificates map[string]*tls.Certificate // SNI -> 验证证书
+}
+
+func NewTlsAlpn01Solver() *TlsAlpn01Solver {
+	return &TlsAlpn01Solver{
+		certificates: make(map[string]*tls.Certificate),
+	}
+}
+
+func (solver *TlsAlpn01Solver) Present(identifier Identifier, keyAuthorization string) error {
+	serverName, err := TlsAlpn01ServerName(identifier)
+	if err != nil {
+		return err
+	}
+	
+	certificate, err := TlsAlpn01Certificate(identifier, keyAuthorization)
+	if err != nil {
+		return err
+	}
+	
+	solver.mu.Lock()
+	defer solver.mu.Unlock()
+	
+	solver.certificates[serverName] = &certificate
+	return nil
+}
+
+// 验证结束后移除证书
+
+func (solver *TlsAlpn01Solver) CleanUp(identifier Identifier) {
+	serverName, err := TlsAlpn01ServerName(identifier)
+	if err != nil {
+		return
+	}
+	
+	solver.mu.Lock()
+	defer solver.mu.Unlock()
+	
+	delete(solver.certificates, serverName)
+}
+
+func (solver *TlsAlpn01Solver) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if len(hello.SupportedProtos) != 1 || hello.SupportedProtos[0] != AcmeTlsAlpnProtocol {
+		return nil, errors.New("tls-alpn-01: client did not negotiate " + AcmeTlsAlpnProtocol)
+	}
+	
+	solver.mu.RLock()
+	defer solver.mu.RUnlock()
+	
+	certificate, ok := solver.certificates[strings.ToLower(hello.ServerName)]
+	if !ok {
+		return nil, errors.New("tls-alpn-01: no certificate for " + hello.ServerName)
+	}
+	
+	return certificate, nil
+}
+
+func (solver *TlsAlpn01Solver) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: solver.GetCertificate,
+		NextProtos:     []string{AcmeTlsAlpnProtocol},
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// 完成 TLS 握手后即关闭连接, CA 只检查握手时的证书
+
+func (solver *TlsAlpn01Solver) Serve(listener net.Listener) error {
+	tlsListener := tls.NewListener(listener, solver.TLSConfig())
+	for {
+		conn, err := tlsListener.Accept()
+		if err != nil {
+			return err
+		}
+		
+		go func(conn net.Conn) {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(10 * time.Second))
+			conn.(*tls.Conn).Handshake()
+		}(conn)
+	}
+}
+
+func (solver *TlsAlpn01Solver) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	
+	return solver.Serve(listener)
+}
@@ -0,0 +1,73 @@
+package step
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/asn1"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+)
+
+func TestTlsAlpn01ServerName(t *testing.T) {
+	serverName, err := TlsAlpn01ServerName(NewIdentifier("WWW.Example.com"))
+	require.NoError(t, err)
+	require.Equal(t, "www.example.com", serverName)
+	
+	serverName, err = TlsAlpn01ServerName(NewIdentifier("192.0.2.1"))
+	require.NoError(t, err)
+	require.Equal(t, "1.2.0.192.in-addr.arpa", serverName)
+}
+
+func TestTlsAlpn01Solver(t *testing.T) {
+	solver := NewTlsAlpn01Solver()
+	identifier := NewIdentifier("www.example.com")
+	require.NoError(t, solver.Present(identifier, "token.thumbprint"))
+	
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go solver.Serve(listener)
+	
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		ServerName:         "www.example.com",
+		NextProtos:         []string{AcmeTlsAlpnProtocol},
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+	
+	state := conn.ConnectionState()
+	conn.Close()
+	require.Equal(t, AcmeTlsAlpnProtocol, state.NegotiatedProtocol)
+	
+	cert := state.PeerCertificates[0]
+	require.Equal(t, []string{"www.example.com"}, cert.DNSNames)
+	
+	// acmeIdentifier 扩展为 critical, 值为 key authorization 的 SHA-256 摘要
+	var extValue []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(idPeAcmeIdentifier) {
+			require.True(t, ext.Critical)
+			_, err = asn1.Unmarshal(ext.Value, &extValue)
+			require.NoError(t, err)
+		}
+	}
+	digest := sha256.Sum256([]byte("token.thumbprint"))
+	require.Equal(t, digest[:], extValue)
+	
+	// 未协商 acme-tls/1 时握手失败
+	_, err = tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		ServerName:         "www.example.com",
+		InsecureSkipVerify: true,
+	})
+	require.Error(t, err)
+	
+	// 验证结束后移除证书
+	solver.CleanUp(identifier)
+	_, err = tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+		ServerName:         "www.example.com",
+		NextProtos:         []string{AcmeTlsAlpnProtocol},
+		InsecureSkipVerify: true,
+	})
+	require.Error(t, err)
+}